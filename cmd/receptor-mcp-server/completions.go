@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/ansible/receptor-mcp/pkg/mcp"
+)
+
+// registerReceptorCompletions completes prompt arguments and resource
+// template variables from live mesh data: node IDs and work types as the
+// healthy entry points last reported them, and the units they hold
+func (h *receptorHandlers) registerReceptorCompletions(server *mcp.Server, configs map[string]workTypeConfig) {
+	server.RegisterCompletion(mcp.CompleteReference{Type: mcp.RefPrompt, Name: "deploy_workflow"},
+		func(ctx context.Context, argument, value string, arguments map[string]string) ([]string, error) {
+			switch argument {
+			case "workflow_type":
+				return h.knownWorkTypes(configs), nil
+			case "target_nodes":
+				return completeList(value, h.knownNodes()), nil
+			}
+			return nil, nil
+		})
+
+	server.RegisterCompletion(mcp.CompleteReference{Type: mcp.RefResource, URI: "receptor://nodes/{node_id}"},
+		func(ctx context.Context, argument, value string, arguments map[string]string) ([]string, error) {
+			return h.knownNodes(), nil
+		})
+
+	for _, uri := range []string{
+		"receptor://work/{unit_id}/status",
+		"receptor://work/{unit_id}/stdout",
+		"receptor://work/{unit_id}/stdout/{chunk}",
+	} {
+		server.RegisterCompletion(mcp.CompleteReference{Type: mcp.RefResource, URI: uri}, h.completeUnitIDs)
+	}
+}
+
+// knownNodes returns the sorted IDs of the nodes the entry points can see
+func (h *receptorHandlers) knownNodes() []string {
+	seen := map[string]bool{}
+	for _, status := range h.healthyStatuses() {
+		for _, id := range status.Nodes() {
+			seen[id] = true
+		}
+	}
+	return sortedKeys(seen)
+}
+
+// knownWorkTypes returns the sorted work types advertised on the mesh or
+// defined in the work type configuration files
+func (h *receptorHandlers) knownWorkTypes(configs map[string]workTypeConfig) []string {
+	seen := map[string]bool{}
+	for workType := range advertisedWorkTypes(h.healthyStatuses()) {
+		seen[workType] = true
+	}
+	for workType := range configs {
+		seen[workType] = true
+	}
+	return sortedKeys(seen)
+}
+
+// completeUnitIDs suggests unit IDs for work URIs, unfinished units first
+func (h *receptorHandlers) completeUnitIDs(ctx context.Context, argument, value string, arguments map[string]string) ([]string, error) {
+	if argument != "unit_id" {
+		return nil, nil
+	}
+	active, finished, err := h.listWorkUnits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(active)+len(finished))
+	for _, unit := range append(active, finished...) {
+		ids = append(ids, unit.WorkID)
+	}
+	return ids, nil
+}
+
+// completeList completes the last entry of a comma-separated list, keeping
+// the entries before it and skipping candidates already listed
+func completeList(value string, candidates []string) []string {
+	prefix := ""
+	if i := strings.LastIndex(value, ","); i >= 0 {
+		last := value[i+1:]
+		prefix = value[:len(value)-len(strings.TrimLeft(last, " "))]
+	}
+	listed := map[string]bool{}
+	for _, entry := range strings.Split(prefix, ",") {
+		listed[strings.TrimSpace(entry)] = true
+	}
+
+	var values []string
+	for _, candidate := range candidates {
+		if !listed[candidate] {
+			values = append(values, prefix+candidate)
+		}
+	}
+	sort.Strings(values)
+	return values
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/ansible/receptor-mcp/pkg/mcp"
+	"github.com/ansible/receptor-mcp/pkg/receptor"
+)
+
+func TestCompleteList(t *testing.T) {
+	candidates := []string{"worker-02", "worker-01", "controller"}
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"", []string{"controller", "worker-01", "worker-02"}},
+		{"wor", []string{"controller", "worker-01", "worker-02"}},
+		// Entries already listed are not offered again
+		{"worker-01,", []string{"worker-01,controller", "worker-01,worker-02"}},
+		{"worker-01, wor", []string{"worker-01, controller", "worker-01, worker-02"}},
+		{"worker-01, worker-02, ", []string{"worker-01, worker-02, controller"}},
+	}
+	for _, tt := range tests {
+		if got := completeList(tt.value, candidates); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("completeList(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestKnownNodesAndWorkTypes(t *testing.T) {
+	h, _, _ := newTestMesh(t)
+	h.pool.Check(context.Background())
+
+	if got, want := h.knownNodes(), []string{"controller-a", "controller-b", "worker-01", "worker-02", "worker-03"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected nodes %v, got %v", want, got)
+	}
+	configs := map[string]workTypeConfig{"echo": {WorkType: "echo"}, "train": {WorkType: "train"}}
+	if got, want := h.knownWorkTypes(configs), []string{"echo", "model-inference", "sleep", "train"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected work types %v, got %v", want, got)
+	}
+}
+
+func TestReceptorCompletions(t *testing.T) {
+	h, a, _ := newTestMesh(t)
+	for i := 0; i < 150; i++ {
+		a.addUnit(fmt.Sprintf("unit%03d", i), receptor.WorkStatus{State: receptor.WorkStateSucceeded}, "")
+	}
+	a.addUnit("unit149", receptor.WorkStatus{State: receptor.WorkStateRunning}, "")
+	h.pool.Check(context.Background())
+
+	server := mcp.NewServer("test-server", "1.0.0")
+	h.registerReceptorResources(server)
+	registerReceptorPrompts(server)
+	h.registerReceptorCompletions(server, nil)
+	client := startMCPClient(t, server)
+
+	complete := func(ref mcp.CompleteReference, argument, value string) mcp.Completion {
+		t.Helper()
+		var response mcp.CompleteResponse
+		client.call("completion/complete", mcp.CompleteRequest{Ref: ref, Argument: mcp.CompleteArgument{Name: argument, Value: value}}, &response)
+		return response.Completion
+	}
+	stdout := mcp.CompleteReference{Type: mcp.RefResource, URI: "receptor://work/{unit_id}/stdout"}
+
+	// Unfinished units come first, and at most 100 are returned
+	completion := complete(stdout, "unit_id", "unit")
+	if len(completion.Values) != 100 || completion.Total != 150 || !completion.HasMore || completion.Values[0] != "unit149" {
+		t.Errorf("Expected 100 of 150 units starting with unit149, got %d of %d (hasMore %t) starting with %v",
+			len(completion.Values), completion.Total, completion.HasMore, completion.Values[:1])
+	}
+	completion = complete(stdout, "unit_id", "unit14")
+	if len(completion.Values) != 10 || completion.HasMore {
+		t.Errorf("Expected the 10 units matching unit14, got %v", completion)
+	}
+
+	completion = complete(mcp.CompleteReference{Type: mcp.RefResource, URI: "receptor://nodes/{node_id}"}, "node_id", "worker-0")
+	if want := []string{"worker-01", "worker-02", "worker-03"}; !reflect.DeepEqual(completion.Values, want) {
+		t.Errorf("Expected %v, got %v", want, completion.Values)
+	}
+
+	deploy := mcp.CompleteReference{Type: mcp.RefPrompt, Name: "deploy_workflow"}
+	completion = complete(deploy, "target_nodes", "worker-01, worker-0")
+	if want := []string{"worker-01, worker-02", "worker-01, worker-03"}; !reflect.DeepEqual(completion.Values, want) {
+		t.Errorf("Expected %v, got %v", want, completion.Values)
+	}
+	completion = complete(deploy, "workflow_type", "m")
+	if want := []string{"model-inference"}; !reflect.DeepEqual(completion.Values, want) {
+		t.Errorf("Expected %v, got %v", want, completion.Values)
+	}
+}
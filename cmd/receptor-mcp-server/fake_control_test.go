@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ansible/receptor-mcp/pkg/mcp"
+	"github.com/ansible/receptor-mcp/pkg/receptor"
+)
+
+// fakeControl is an in-process stand-in for a Receptor control service
+type fakeControl struct {
+	t        *testing.T
+	listener net.Listener
+
+	mu       sync.Mutex
+	commands []map[string]interface{}
+	units    map[string]receptor.WorkStatus
+	results  map[string]string
+	status   receptor.Status
+	// finishSubmitted makes submitted work succeed at once with
+	// submitOutput as its stdout
+	finishSubmitted bool
+	submitOutput    string
+	// stallAfterSubmit is how many connections after each submit get no
+	// greeting until the client hangs up, as from an overloaded node
+	stallAfterSubmit int
+	stall            int
+}
+
+// newFakeControl starts a fake control service for node on a Unix socket
+func newFakeControl(t *testing.T, node string) *fakeControl {
+	t.Helper()
+	listener, err := net.Listen("unix", filepath.Join(t.TempDir(), "control.sock"))
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	f := &fakeControl{
+		t:        t,
+		listener: listener,
+		units:    map[string]receptor.WorkStatus{},
+		results:  map[string]string{},
+		status: receptor.Status{
+			NodeID:       node,
+			RoutingTable: map[string]string{},
+		},
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+// addUnit adds a work unit whose stdout is output
+func (f *fakeControl) addUnit(unitID string, status receptor.WorkStatus, output string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	status.StdoutSize = int64(len(output))
+	f.units[unitID] = status
+	f.results[unitID] = output
+}
+
+// advertise makes node advertise workTypes, routing to it if it is not
+// the fake's own node
+func (f *fakeControl) advertise(node string, secure bool, workTypes ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ad := receptor.Advertisement{NodeID: node, Service: "control"}
+	for _, workType := range workTypes {
+		ad.WorkCommands = append(ad.WorkCommands, receptor.WorkCommand{WorkType: workType, Secure: secure})
+	}
+	f.status.Advertisements = append(f.status.Advertisements, ad)
+	if node != f.status.NodeID {
+		f.status.RoutingTable[node] = f.status.NodeID
+	}
+}
+
+// released reports whether a unit has been released
+func (f *fakeControl) released(unitID string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, exists := f.units[unitID]
+	return !exists
+}
+
+// lastCommand returns the most recent command the fake received
+func (f *fakeControl) lastCommand() map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.commands) == 0 {
+		return nil
+	}
+	return f.commands[len(f.commands)-1]
+}
+
+func (f *fakeControl) serve(conn net.Conn) {
+	defer conn.Close()
+
+	f.mu.Lock()
+	stall := f.stall > 0
+	if stall {
+		f.stall--
+	}
+	f.mu.Unlock()
+	if stall {
+		io.Copy(io.Discard, conn)
+		return
+	}
+	io.WriteString(conn, "Receptor Control, node "+f.status.NodeID+"\n")
+
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return
+	}
+	line = strings.TrimSpace(line)
+
+	cmd := map[string]interface{}{}
+	if strings.HasPrefix(line, "{") {
+		json.Unmarshal([]byte(line), &cmd)
+	} else {
+		cmd["command"] = line
+	}
+	f.mu.Lock()
+	f.commands = append(f.commands, cmd)
+	f.mu.Unlock()
+
+	reply := func(v interface{}) {
+		data, _ := json.Marshal(v)
+		conn.Write(append(data, '\n'))
+	}
+
+	unitID, _ := cmd["unitid"].(string)
+	f.mu.Lock()
+	_, known := f.units[unitID]
+	f.mu.Unlock()
+
+	switch {
+	case cmd["command"] == "status":
+		f.mu.Lock()
+		data, _ := json.Marshal(f.status)
+		f.mu.Unlock()
+		conn.Write(append(data, '\n'))
+	case cmd["subcommand"] == "submit":
+		f.mu.Lock()
+		id := fmt.Sprintf("unit%d", len(f.commands))
+		f.mu.Unlock()
+		io.WriteString(conn, "Work unit created with ID "+id+". Send stdin data and EOF.\n")
+		io.ReadAll(reader)
+		f.mu.Lock()
+		status := receptor.WorkStatus{State: receptor.WorkStatePending, WorkType: cmd["worktype"].(string)}
+		if f.finishSubmitted {
+			status.State, status.StdoutSize = receptor.WorkStateSucceeded, int64(len(f.submitOutput))
+			f.results[id] = f.submitOutput
+		}
+		f.units[id] = status
+		f.stall = f.stallAfterSubmit
+		f.mu.Unlock()
+		reply(map[string]string{"result": "Job Started", "unitid": id})
+	case cmd["subcommand"] == "list":
+		f.mu.Lock()
+		data, _ := json.Marshal(f.units)
+		f.mu.Unlock()
+		conn.Write(append(data, '\n'))
+	case !known:
+		io.WriteString(conn, "ERROR: unknown work unit "+unitID+"\n")
+	case cmd["subcommand"] == "status":
+		f.mu.Lock()
+		status := f.units[unitID]
+		f.mu.Unlock()
+		reply(status)
+	case cmd["subcommand"] == "cancel":
+		f.mu.Lock()
+		status := f.units[unitID]
+		status.State = receptor.WorkStateCanceled
+		f.units[unitID] = status
+		f.mu.Unlock()
+		reply(map[string]string{"cancelled": unitID})
+	case cmd["subcommand"] == "release":
+		f.mu.Lock()
+		delete(f.units, unitID)
+		f.mu.Unlock()
+		reply(map[string]string{"released": unitID})
+	case cmd["subcommand"] == "results":
+		f.mu.Lock()
+		results := f.results[unitID]
+		f.mu.Unlock()
+		start := int(cmd["startpos"].(float64))
+		io.WriteString(conn, "Streaming results for work unit "+unitID+"\n")
+		if start < len(results) {
+			io.WriteString(conn, results[start:])
+		}
+	default:
+		io.WriteString(conn, "ERROR: unknown command\n")
+	}
+}
+
+// newTestHandlers returns handlers backed by fake, and any further fakes as
+// other entry points named after their nodes, and a fresh work store
+func newTestHandlers(t *testing.T, fake *fakeControl, others ...*fakeControl) *receptorHandlers {
+	t.Helper()
+	endpoints := []receptor.Endpoint{{
+		Name:   "localhost",
+		Dialer: receptor.UnixDialer{Path: fake.listener.Addr().String()},
+	}}
+	for _, other := range others {
+		endpoints = append(endpoints, receptor.Endpoint{
+			Name:   other.status.NodeID,
+			Dialer: receptor.UnixDialer{Path: other.listener.Addr().String()},
+		})
+	}
+	pool, err := receptor.NewPool(endpoints, 5*time.Second, time.Minute)
+	if err != nil {
+		t.Fatalf("NewPool returned error: %v", err)
+	}
+	store, err := openWorkStore(filepath.Join(t.TempDir(), "work-history.jsonl"), 0)
+	if err != nil {
+		t.Fatalf("openWorkStore returned error: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	return &receptorHandlers{
+		pool:            pool,
+		store:           store,
+		logger:          mcp.NewServer("test-server", "1.0.0").Logger("receptor.history"),
+		workTimeout:     time.Minute,
+		signedWorkTypes: map[string]bool{},
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ansible/receptor-mcp/pkg/mcp"
+	"github.com/ansible/receptor-mcp/pkg/receptor"
+	"github.com/ansible/receptor-mcp/pkg/workstore"
+)
+
+// releasedState is recorded for tracked units released, or gone from the
+// mesh, before the server saw them finish
+const releasedState = "Released"
+
+// maxWorkStores bounds how many work store files are tried when other
+// server instances hold the configured one
+const maxWorkStores = 8
+
+// openWorkStore opens the work store at path. While other instances hold
+// it, such as several stdio servers started by one desktop client, the
+// first free one of path.1, path.2 and so on is used instead, so each
+// instance keeps a history of its own.
+func openWorkStore(path string, maxHistory int) (*workstore.Store, error) {
+	ext := filepath.Ext(path)
+	candidate := path
+	for i := 1; ; i++ {
+		store, err := workstore.Open(candidate, maxHistory)
+		if !errors.Is(err, workstore.ErrLocked) || i == maxWorkStores {
+			return store, err
+		}
+		candidate = fmt.Sprintf("%s.%d%s", strings.TrimSuffix(path, ext), i, ext)
+	}
+}
+
+// workRecordEntry is a tracked work unit as listed in the queue and
+// history resources
+type workRecordEntry struct {
+	WorkID      string     `json:"work_id"`
+	Status      string     `json:"status"`
+	WorkType    string     `json:"work_type"`
+	NodeID      string     `json:"node_id"`
+	Client      string     `json:"client,omitempty"`
+	ParamsHash  string     `json:"params_hash,omitempty"`
+	Detail      string     `json:"detail,omitempty"`
+	StdoutSize  int64      `json:"stdout_size"`
+	SubmittedAt time.Time  `json:"submitted_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	FetchedAt   *time.Time `json:"fetched_at,omitempty"`
+	ReleasedAt  *time.Time `json:"released_at,omitempty"`
+}
+
+func newWorkRecordEntry(record workstore.Record) workRecordEntry {
+	return workRecordEntry{
+		WorkID:      record.UnitID,
+		Status:      record.State,
+		WorkType:    record.WorkType,
+		NodeID:      record.Node,
+		Client:      record.Client,
+		ParamsHash:  record.ParamsHash,
+		Detail:      record.Detail,
+		StdoutSize:  record.StdoutSize,
+		SubmittedAt: record.SubmittedAt,
+		UpdatedAt:   record.UpdatedAt,
+		FinishedAt:  record.FinishedAt,
+		FetchedAt:   record.FetchedAt,
+		ReleasedAt:  record.ReleasedAt,
+	}
+}
+
+// recordSubmission adds a newly submitted unit to the work store. Failing
+// to record it does not fail the submission.
+func (h *receptorHandlers) recordSubmission(ctx context.Context, unitID string, req receptor.WorkRequest) {
+	now := time.Now().UTC()
+	record := workstore.Record{
+		UnitID:      unitID,
+		Node:        req.Node,
+		WorkType:    req.WorkType,
+		ParamsHash:  workstore.HashParams(req.Params),
+		SubmittedAt: now,
+		UpdatedAt:   now,
+		State:       receptor.WorkStatePending.String(),
+	}
+	if client, ok := mcp.ClientFromContext(ctx); ok {
+		record.Client = client.Name + "/" + client.Version
+	}
+	if err := h.store.Put(record); err != nil {
+		h.logger.Warningf("Recording submission of work %s failed: %v", unitID, err)
+	}
+}
+
+// recordStatus updates a tracked unit with its latest status. Units not
+// submitted through this server, and units already finished, are left
+// alone.
+func (h *receptorHandlers) recordStatus(unitID string, status *receptor.WorkStatus) {
+	err := h.store.Update(unitID, func(record *workstore.Record) {
+		h.applyStatus(record, status)
+	})
+	if err != nil {
+		h.logger.Warningf("Recording status of work %s failed: %v", unitID, err)
+	}
+}
+
+// applyStatus copies status into an unfinished record
+func (h *receptorHandlers) applyStatus(record *workstore.Record, status *receptor.WorkStatus) {
+	state := status.State.String()
+	if record.Finished() || (record.State == state && record.Detail == status.Detail && record.StdoutSize == status.StdoutSize) {
+		return
+	}
+
+	now := time.Now().UTC()
+	record.State, record.Detail, record.StdoutSize = state, status.Detail, status.StdoutSize
+	record.UpdatedAt = now
+	if status.State.Final() {
+		record.FinishedAt = &now
+		h.logger.Debugf("Work %s finished: %s", record.UnitID, state)
+	}
+}
+
+// trackWork refreshes the unfinished units in the work store every
+// interval until ctx is done, so their final state is recorded even when
+// no client asks for it
+func (h *receptorHandlers) trackWork(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := h.refreshWork(ctx); err != nil {
+			h.logger.Debugf("Refreshing tracked work failed: %v", err)
+		}
+	}
+}
+
+// refreshWork records the current status of every unfinished tracked unit.
+// Units missing from the mesh are recorded as released, but only while
+// every entry point is healthy, since a unit may live behind one that is
+// not.
+func (h *receptorHandlers) refreshWork(ctx context.Context) error {
+	active := h.store.Active()
+	if len(active) == 0 {
+		return nil
+	}
+	units, err := h.pool.ListWork(ctx)
+	if err != nil {
+		return err
+	}
+
+	allHealthy := h.allHealthy()
+	for _, record := range active {
+		if status, ok := units[record.UnitID]; ok {
+			h.recordStatus(record.UnitID, &status)
+		} else if allHealthy {
+			h.recordReleased(record.UnitID)
+		}
+	}
+	return nil
+}
+
+// allHealthy reports whether every entry point is healthy, so a unit none
+// of them knows no longer exists
+func (h *receptorHandlers) allHealthy() bool {
+	for _, state := range h.pool.States() {
+		if !state.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// recordFetched notes that the output of a finished tracked unit was read
+// up to end, which makes it eligible for the reaper once the read reaches
+// the end of its stdout. The server's own polls for subscribers do not count.
+func (h *receptorHandlers) recordFetched(ctx context.Context, unitID string, status *receptor.WorkStatus, end int64) {
+	if isPoll(ctx) || !status.State.Final() || end < status.StdoutSize {
+		return
+	}
+	err := h.store.Update(unitID, func(record *workstore.Record) {
+		h.applyStatus(record, status)
+		now := time.Now().UTC()
+		record.UpdatedAt = now
+		record.FetchedAt = &now
+	})
+	if err != nil {
+		h.logger.Warningf("Recording fetch of work %s failed: %v", unitID, err)
+	}
+}
+
+// recordReleased marks a tracked unit as released from the mesh. A unit
+// released before it finished is recorded in the released state.
+func (h *receptorHandlers) recordReleased(unitID string) {
+	err := h.store.Update(unitID, func(record *workstore.Record) {
+		if record.Released() {
+			return
+		}
+		now := time.Now().UTC()
+		record.UpdatedAt = now
+		record.ReleasedAt = &now
+		if !record.Finished() {
+			record.State = releasedState
+			record.FinishedAt = &now
+		}
+	})
+	if err != nil {
+		h.logger.Warningf("Recording release of work %s failed: %v", unitID, err)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ansible/receptor-mcp/pkg/mcp"
+	"github.com/ansible/receptor-mcp/pkg/receptor"
+)
+
+func TestOpenWorkStoreFallsBackWhenLocked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "work-history.jsonl")
+
+	first, err := openWorkStore(path, 0)
+	if err != nil {
+		t.Fatalf("openWorkStore returned error: %v", err)
+	}
+	defer first.Close()
+	second, err := openWorkStore(path, 0)
+	if err != nil {
+		t.Fatalf("openWorkStore returned error: %v", err)
+	}
+	defer second.Close()
+
+	if first.Path() != path {
+		t.Errorf("Expected the first instance to use %s, got %s", path, first.Path())
+	}
+	if want := filepath.Join(filepath.Dir(path), "work-history.1.jsonl"); second.Path() != want {
+		t.Errorf("Expected the second instance to use %s, got %s", want, second.Path())
+	}
+}
+
+// resourceIDs decodes a queue or history resource into the work IDs of
+// each of its lists, sorted
+func resourceIDs(t *testing.T, result interface{}) map[string][]string {
+	t.Helper()
+	var lists map[string][]workRecordEntry
+	if err := json.Unmarshal([]byte(result.(mcp.ResourcesReadResponse).Contents[0].Text), &lists); err != nil {
+		t.Fatalf("Invalid resource: %v", err)
+	}
+	ids := map[string][]string{}
+	for name, entries := range lists {
+		ids[name] = []string{}
+		for _, entry := range entries {
+			ids[name] = append(ids[name], entry.WorkID)
+		}
+		sort.Strings(ids[name])
+	}
+	return ids
+}
+
+// submitTracked records a unit as submitted through the server and adds it
+// to the fake
+func submitTracked(h *receptorHandlers, fake *fakeControl, unitID string) {
+	fake.addUnit(unitID, receptor.WorkStatus{State: receptor.WorkStatePending, WorkType: "echo"}, "")
+	h.recordSubmission(context.Background(), unitID, receptor.WorkRequest{Node: "worker-01", WorkType: "echo"})
+}
+
+func TestRefreshWork(t *testing.T) {
+	fake := newFakeControl(t, "controller")
+	h := newTestHandlers(t, fake)
+	for _, id := range []string{"pending", "running", "succeeded", "failed", "gone"} {
+		submitTracked(h, fake, id)
+	}
+	fake.addUnit("running", receptor.WorkStatus{State: receptor.WorkStateRunning, WorkType: "echo"}, "partial")
+	fake.addUnit("succeeded", receptor.WorkStatus{State: receptor.WorkStateSucceeded, WorkType: "echo"}, "done\n")
+	fake.addUnit("failed", receptor.WorkStatus{State: receptor.WorkStateFailed, Detail: "exit status 1", WorkType: "echo"}, "")
+	fake.mu.Lock()
+	delete(fake.units, "gone")
+	fake.mu.Unlock()
+	// Submitted by someone else, and not tracked
+	fake.addUnit("other", receptor.WorkStatus{State: receptor.WorkStateSucceeded, WorkType: "echo"}, "")
+	h.pool.Check(context.Background())
+
+	if err := h.refreshWork(context.Background()); err != nil {
+		t.Fatalf("refreshWork returned error: %v", err)
+	}
+	want := map[string]string{
+		"pending":   "Pending",
+		"running":   "Running",
+		"succeeded": "Succeeded",
+		"failed":    "Failed",
+		"gone":      releasedState,
+	}
+	for id, state := range want {
+		record, _ := h.store.Get(id)
+		if record.State != state || record.Finished() != (id != "pending" && id != "running") {
+			t.Errorf("%s: expected %s, got %+v", id, state, record)
+		}
+	}
+	if record, _ := h.store.Get("running"); record.StdoutSize != int64(len("partial")) {
+		t.Errorf("Expected the output size to be recorded, got %+v", record)
+	}
+	if record, _ := h.store.Get("failed"); record.Detail != "exit status 1" {
+		t.Errorf("Expected the detail to be recorded, got %+v", record)
+	}
+	if _, ok := h.store.Get("other"); ok {
+		t.Error("Expected untracked work to stay untracked")
+	}
+
+	queue, err := h.handleWorkQueueResource(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("handleWorkQueueResource returned error: %v", err)
+	}
+	if got, want := resourceIDs(t, queue), map[string][]string{"pending": {"pending"}, "active": {"running"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected queue %v, got %v", want, got)
+	}
+	history, err := h.handleWorkHistoryResource(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("handleWorkHistoryResource returned error: %v", err)
+	}
+	if got, want := resourceIDs(t, history), map[string][]string{"completed": {"succeeded"}, "failed": {"failed", "gone"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected history %v, got %v", want, got)
+	}
+}
+
+func TestRefreshWorkKeepsUnitsBehindUnhealthyEntryPoints(t *testing.T) {
+	fake := newFakeControl(t, "controller")
+	other := newFakeControl(t, "controller-b")
+	h := newTestHandlers(t, fake, other)
+	submitTracked(h, other, "remote")
+	other.listener.Close()
+	h.pool.Check(context.Background())
+
+	if err := h.refreshWork(context.Background()); err != nil {
+		t.Fatalf("refreshWork returned error: %v", err)
+	}
+	if record, _ := h.store.Get("remote"); record.Finished() {
+		t.Errorf("Expected work behind an unhealthy entry point to stay active, got %+v", record)
+	}
+}
+
+func TestTrackWork(t *testing.T) {
+	fake := newFakeControl(t, "controller")
+	h := newTestHandlers(t, fake)
+	submitTracked(h, fake, "unitA")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.trackWork(ctx, 10*time.Millisecond)
+	fake.addUnit("unitA", receptor.WorkStatus{State: receptor.WorkStateSucceeded, WorkType: "echo"}, "done\n")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if record, _ := h.store.Get("unitA"); record.Finished() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("Expected trackWork to record the unit finishing")
+}
@@ -2,14 +2,19 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/ansible/receptor-mcp/pkg/mcp"
+	"github.com/ansible/receptor-mcp/pkg/receptor"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -17,6 +22,10 @@ import (
 const (
 	appName    = "receptor-mcp-server"
 	appVersion = "1.0.0"
+
+	// cancelGracePeriod is how long cancelled HTTP requests have to
+	// answer before their connections are closed
+	cancelGracePeriod = time.Second
 )
 
 var (
@@ -48,16 +57,28 @@ func init() {
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "enable debug logging")
 
 	// Server configuration flags
+	rootCmd.Flags().String("listen", "", "serve MCP over Streamable HTTP on this address (e.g. :8889, which binds to 127.0.0.1) instead of stdio")
+	rootCmd.Flags().String("auth-token-file", "", "file holding the bearer token HTTP clients must send")
 	rootCmd.Flags().String("receptor-socket", "/tmp/receptor/receptor.sock", "path to Receptor control socket")
-	rootCmd.Flags().StringSlice("receptor-nodes", []string{"localhost"}, "list of Receptor nodes to connect to")
-	rootCmd.Flags().Duration("timeout", 30, "default timeout for Receptor operations (seconds)")
+	rootCmd.Flags().String("receptor-address", "", "host:port of a TLS control service listener (overrides --receptor-socket)")
+	rootCmd.Flags().StringSlice("receptor-nodes", []string{"localhost"}, "control service entry points: localhost (the configured socket/address), a socket path or a TLS host:port")
+	rootCmd.Flags().Int("timeout", 30, "default timeout for Receptor operations (seconds)")
 	rootCmd.Flags().Bool("tls-verify", true, "verify TLS certificates for Receptor connections")
+	rootCmd.Flags().String("tls-cert", "", "client certificate for TLS Receptor connections")
+	rootCmd.Flags().String("tls-key", "", "client key for TLS Receptor connections")
+	rootCmd.Flags().String("tls-ca", "", "CA certificate used to verify the Receptor control service")
 
 	// Bind flags to viper
+	viper.BindPFlag("server.listen", rootCmd.Flags().Lookup("listen"))
+	viper.BindPFlag("server.auth_token_file", rootCmd.Flags().Lookup("auth-token-file"))
 	viper.BindPFlag("receptor.socket", rootCmd.Flags().Lookup("receptor-socket"))
+	viper.BindPFlag("receptor.address", rootCmd.Flags().Lookup("receptor-address"))
 	viper.BindPFlag("receptor.nodes", rootCmd.Flags().Lookup("receptor-nodes"))
 	viper.BindPFlag("receptor.timeout", rootCmd.Flags().Lookup("timeout"))
 	viper.BindPFlag("receptor.tls_verify", rootCmd.Flags().Lookup("tls-verify"))
+	viper.BindPFlag("receptor.tls.cert", rootCmd.Flags().Lookup("tls-cert"))
+	viper.BindPFlag("receptor.tls.key", rootCmd.Flags().Lookup("tls-key"))
+	viper.BindPFlag("receptor.tls.ca", rootCmd.Flags().Lookup("tls-ca"))
 	viper.BindPFlag("debug", rootCmd.PersistentFlags().Lookup("debug"))
 }
 
@@ -77,10 +98,24 @@ func initConfig() {
 
 	// Set defaults
 	viper.SetDefault("receptor.socket", "/tmp/receptor/receptor.sock")
+	viper.SetDefault("receptor.address", "")
 	viper.SetDefault("receptor.nodes", []string{"localhost"})
 	viper.SetDefault("receptor.timeout", 30)
 	viper.SetDefault("receptor.tls_verify", true)
+	viper.SetDefault("receptor.health_interval", 15)
 	viper.SetDefault("debug", false)
+	viper.SetDefault("server.shutdown_timeout", 10)
+	viper.SetDefault("server.page_size", mcp.DefaultPageSize)
+	viper.SetDefault("server.session_idle_timeout", int(mcp.DefaultSessionIdleTimeout/time.Second))
+	viper.SetDefault("tools.max_concurrent_work", 10)
+	viper.SetDefault("tools.release_after", 0)
+	viper.SetDefault("tools.default_work_timeout", 300)
+	viper.SetDefault("receptor.work_signing.token_expiration", int(receptor.DefaultTokenExpiration/time.Second))
+	viper.SetDefault("resources.topology_refresh", 30)
+	viper.SetDefault("resources.node_status_refresh", 10)
+	viper.SetDefault("resources.work_queue_refresh", 5)
+	viper.SetDefault("resources.max_history_entries", 1000)
+	viper.SetDefault("resources.history_file", defaultHistoryFile())
 
 	// Read config file if it exists
 	if err := viper.ReadInConfig(); err == nil {
@@ -88,6 +123,17 @@ func initConfig() {
 	}
 }
 
+// defaultHistoryFile is where work history is kept unless
+// resources.history_file says otherwise: the user's cache directory, or
+// the current directory when there is none
+func defaultHistoryFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "receptor-mcp-history.jsonl"
+	}
+	return filepath.Join(dir, appName, "work-history.jsonl")
+}
+
 func runServer(cmd *cobra.Command, args []string) error {
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -102,372 +148,307 @@ func runServer(cmd *cobra.Command, args []string) error {
 		cancel()
 	}()
 
-	// Create MCP server
-	server := mcp.NewServer(appName, appVersion)
-
 	// Configure logging
+	logLevel := mcp.LogLevelInfo
 	if viper.GetBool("debug") {
-		log.SetFlags(log.LstdFlags | log.Lshortfile)
+		logLevel = mcp.LogLevelDebug
 		fmt.Fprintf(os.Stderr, "Debug logging enabled\n")
 	}
 
-	// Register Receptor tools (placeholder implementations for Phase 1)
-	registerReceptorTools(server)
-	registerReceptorResources(server)
+	// Create MCP server
+	server := mcp.NewServer(appName, appVersion,
+		mcp.WithMaxConcurrentRequests(viper.GetInt("tools.max_concurrent_work")),
+		mcp.WithShutdownTimeout(time.Duration(viper.GetInt("server.shutdown_timeout"))*time.Second),
+		mcp.WithPageSize(viper.GetInt("server.page_size")),
+		mcp.WithLogLevel(logLevel),
+	)
+
+	// Connect tools to the Receptor control service
+	endpoints, err := newEndpoints(viper.GetStringSlice("receptor.nodes"))
+	if err != nil {
+		return err
+	}
+	pool, err := receptor.NewPool(endpoints,
+		time.Duration(viper.GetInt("receptor.timeout"))*time.Second,
+		time.Duration(viper.GetInt("receptor.health_interval"))*time.Second,
+	)
+	if err != nil {
+		return err
+	}
+	pool.SetLogger(server.Logger("receptor.pool"))
+	pool.SetWorkLogger(server.Logger("receptor.work"))
+	go pool.Run(ctx)
+
+	// Track submitted work across restarts
+	store, err := openWorkStore(viper.GetString("resources.history_file"), viper.GetInt("resources.max_history_entries"))
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	// Work-command definitions of the mesh's work types
+	workTypeConfigs, err := loadWorkTypeConfigs(viper.GetStringSlice("tools.work_type_configs"))
+	if err != nil {
+		return err
+	}
+	signer, err := newWorkSigner()
+	if err != nil {
+		return err
+	}
+
+	handlers := &receptorHandlers{
+		pool:            pool,
+		store:           store,
+		logger:          server.Logger("receptor.history"),
+		workTimeout:     time.Duration(viper.GetInt("tools.default_work_timeout")) * time.Second,
+		signer:          signer,
+		signedWorkTypes: signedWorkTypes(workTypeConfigs),
+	}
+	trackInterval := time.Duration(viper.GetInt("resources.work_queue_refresh")) * time.Second
+	if trackInterval <= 0 {
+		trackInterval = time.Duration(viper.GetInt("receptor.health_interval")) * time.Second
+	}
+	go handlers.trackWork(ctx, trackInterval)
+	if releaseAfter := viper.GetInt("tools.release_after"); releaseAfter > 0 {
+		go handlers.reapWork(ctx, time.Duration(releaseAfter)*time.Second)
+	}
+
+	// Register Receptor tools, resources and prompts
+	handlers.registerReceptorTools(server)
+	handlers.registerReleaseTools(server)
+	handlers.registerRunTool(server)
+	handlers.registerReceptorResources(server)
 	registerReceptorPrompts(server)
 
+	// Offer a tool per work type advertised on the mesh
+	go handlers.syncWorkTypeTools(ctx, server, workTypeConfigs, time.Duration(viper.GetInt("receptor.health_interval"))*time.Second)
+	handlers.registerReceptorCompletions(server, workTypeConfigs)
+
+	// Poll subscribed resources for changes
+	handlers.watchResources(ctx, server,
+		time.Duration(viper.GetInt("resources.topology_refresh"))*time.Second,
+		time.Duration(viper.GetInt("resources.node_status_refresh"))*time.Second,
+		time.Duration(viper.GetInt("resources.work_queue_refresh"))*time.Second,
+	)
+
 	// Log configuration
 	fmt.Fprintf(os.Stderr, "Starting %s v%s\n", appName, appVersion)
-	fmt.Fprintf(os.Stderr, "Receptor socket: %s\n", viper.GetString("receptor.socket"))
+	if address := viper.GetString("receptor.address"); address != "" {
+		fmt.Fprintf(os.Stderr, "Receptor control service: %s (TLS)\n", address)
+	} else {
+		fmt.Fprintf(os.Stderr, "Receptor socket: %s\n", viper.GetString("receptor.socket"))
+	}
 	fmt.Fprintf(os.Stderr, "Receptor nodes: %v\n", viper.GetStringSlice("receptor.nodes"))
+	fmt.Fprintf(os.Stderr, "Work history: %s\n", store.Path())
+	if signer != nil {
+		fmt.Fprintf(os.Stderr, "Work signing key: %s\n", viper.GetString("receptor.work_signing.private_key"))
+	}
+
+	if listen := viper.GetString("server.listen"); listen != "" {
+		return serveHTTP(ctx, server, listen)
+	}
+
 	fmt.Fprintf(os.Stderr, "Ready for MCP communication via stdio\n")
 
 	// Start the MCP server
-	return server.Run(ctx)
+	return server.Run(ctx, mcp.NewStdioTransport())
 }
 
-// registerReceptorTools registers the 7 Receptor tools defined in the design
-func registerReceptorTools(server *mcp.Server) {
-	// Tool 1: submit_work
-	server.RegisterTool(mcp.Tool{
-		Name:        "submit_work",
-		Description: "Submit work to a Receptor node for execution",
-		InputSchema: map[string]interface{}{
-			"type": "object",
-			"properties": map[string]interface{}{
-				"node_id": map[string]interface{}{
-					"type":        "string",
-					"description": "Target node ID for work execution",
-				},
-				"work_type": map[string]interface{}{
-					"type":        "string",
-					"description": "Type of work to execute (e.g., ai-script, compute-task)",
-				},
-				"payload": map[string]interface{}{
-					"type":        "string",
-					"description": "Work payload data",
-				},
-				"params": map[string]interface{}{
-					"type":        "object",
-					"description": "Additional parameters for work execution",
-				},
-			},
-			"required": []string{"node_id", "work_type", "payload"},
-		},
-	}, handleSubmitWork)
-
-	// Tool 2: get_work_status
-	server.RegisterTool(mcp.Tool{
-		Name:        "get_work_status",
-		Description: "Get the status of submitted work",
-		InputSchema: map[string]interface{}{
-			"type": "object",
-			"properties": map[string]interface{}{
-				"work_id": map[string]interface{}{
-					"type":        "string",
-					"description": "Work ID returned from submit_work",
-				},
-			},
-			"required": []string{"work_id"},
-		},
-	}, handleGetWorkStatus)
-
-	// Tool 3: list_nodes
-	server.RegisterTool(mcp.Tool{
-		Name:        "list_nodes",
-		Description: "List all nodes in the Receptor mesh",
-		InputSchema: map[string]interface{}{
-			"type": "object",
-			"properties": map[string]interface{}{
-				"filter": map[string]interface{}{
-					"type":        "string",
-					"description": "Optional filter for node selection",
-				},
-			},
-		},
-	}, handleListNodes)
-
-	// Tool 4: get_node_info
-	server.RegisterTool(mcp.Tool{
-		Name:        "get_node_info",
-		Description: "Get detailed information about a specific node",
-		InputSchema: map[string]interface{}{
-			"type": "object",
-			"properties": map[string]interface{}{
-				"node_id": map[string]interface{}{
-					"type":        "string",
-					"description": "Node ID to get information for",
-				},
-			},
-			"required": []string{"node_id"},
-		},
-	}, handleGetNodeInfo)
-
-	// Tool 5: get_mesh_status
-	server.RegisterTool(mcp.Tool{
-		Name:        "get_mesh_status",
-		Description: "Get overall mesh network status and topology",
-		InputSchema: map[string]interface{}{
-			"type": "object",
-			"properties": map[string]interface{}{},
-		},
-	}, handleGetMeshStatus)
-
-	// Tool 6: cancel_work
-	server.RegisterTool(mcp.Tool{
-		Name:        "cancel_work",
-		Description: "Cancel running or pending work",
-		InputSchema: map[string]interface{}{
-			"type": "object",
-			"properties": map[string]interface{}{
-				"work_id": map[string]interface{}{
-					"type":        "string",
-					"description": "Work ID to cancel",
-				},
-			},
-			"required": []string{"work_id"},
-		},
-	}, handleCancelWork)
-
-	// Tool 7: get_work_results
-	server.RegisterTool(mcp.Tool{
-		Name:        "get_work_results",
-		Description: "Retrieve results from completed work",
-		InputSchema: map[string]interface{}{
-			"type": "object",
-			"properties": map[string]interface{}{
-				"work_id": map[string]interface{}{
-					"type":        "string",
-					"description": "Work ID to get results for",
-				},
-			},
-			"required": []string{"work_id"},
-		},
-	}, handleGetWorkResults)
-}
-
-// registerReceptorResources registers the 4 Receptor resources defined in the design
-func registerReceptorResources(server *mcp.Server) {
-	// Resource 1: mesh_topology
-	server.RegisterResource(mcp.Resource{
-		URI:         "receptor://mesh/topology",
-		Name:        "Mesh Topology",
-		Description: "Real-time mesh network topology information",
-		MimeType:    "application/json",
-	}, handleMeshTopologyResource)
-
-	// Resource 2: node_status
-	server.RegisterResource(mcp.Resource{
-		URI:         "receptor://nodes/status",
-		Name:        "Node Status",
-		Description: "Current status of all nodes in the mesh",
-		MimeType:    "application/json",
-	}, handleNodeStatusResource)
-
-	// Resource 3: work_queue
-	server.RegisterResource(mcp.Resource{
-		URI:         "receptor://work/queue",
-		Name:        "Work Queue",
-		Description: "Active and pending work items",
-		MimeType:    "application/json",
-	}, handleWorkQueueResource)
-
-	// Resource 4: work_history
-	server.RegisterResource(mcp.Resource{
-		URI:         "receptor://work/history",
-		Name:        "Work History",
-		Description: "Historical work execution data",
-		MimeType:    "application/json",
-	}, handleWorkHistoryResource)
-}
+// serveHTTP serves the MCP Streamable HTTP transport at /mcp until ctx is done
+func serveHTTP(ctx context.Context, server *mcp.Server, listen string) error {
+	listen, token, err := httpListenConfig(listen)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Ready for MCP communication via HTTP at http://%s/mcp\n", listener.Addr())
 
-// registerReceptorPrompts registers the 3 Receptor prompts defined in the design
-func registerReceptorPrompts(server *mcp.Server) {
-	// Prompt 1: deploy_workflow
-	server.RegisterPrompt(mcp.Prompt{
-		Name:        "deploy_workflow",
-		Description: "Guide for deploying complex workflows across the mesh",
-		Arguments: []mcp.PromptArgument{
-			{Name: "workflow_type", Description: "Type of workflow to deploy", Required: true},
-			{Name: "target_nodes", Description: "Target nodes for deployment", Required: false},
-		},
-	}, handleDeployWorkflowPrompt)
-
-	// Prompt 2: troubleshoot_mesh
-	server.RegisterPrompt(mcp.Prompt{
-		Name:        "troubleshoot_mesh",
-		Description: "Mesh network troubleshooting assistant",
-		Arguments: []mcp.PromptArgument{
-			{Name: "issue_type", Description: "Type of issue being experienced", Required: false},
-		},
-	}, handleTroubleshootMeshPrompt)
-
-	// Prompt 3: optimize_workload
-	server.RegisterPrompt(mcp.Prompt{
-		Name:        "optimize_workload",
-		Description: "Workload optimization recommendations",
-		Arguments: []mcp.PromptArgument{
-			{Name: "workload_pattern", Description: "Current workload pattern", Required: false},
-			{Name: "performance_goals", Description: "Performance optimization goals", Required: false},
-		},
-	}, handleOptimizeWorkloadPrompt)
+	shutdownTimeout := time.Duration(viper.GetInt("server.shutdown_timeout")) * time.Second
+	return serveMCPHTTP(ctx, server, listener, token, shutdownTimeout)
 }
 
-// Placeholder tool handlers (Phase 1 - basic responses)
-func handleSubmitWork(ctx context.Context, params json.RawMessage) (interface{}, error) {
-	return map[string]interface{}{
-		"work_id": "work_123456",
-		"status":  "submitted",
-		"message": "Work submitted successfully (Phase 1 placeholder)",
-	}, nil
-}
+// serveMCPHTTP serves MCP on listener until ctx is done, then waits up to
+// shutdownTimeout for in-flight requests before cancelling them
+func serveMCPHTTP(ctx context.Context, server *mcp.Server, listener net.Listener, token string, shutdownTimeout time.Duration) error {
+	handler := mcp.NewHTTPHandler(server)
+	handler.AllowedOrigins = viper.GetStringSlice("server.allowed_origins")
+	handler.Token = token
+	handler.IdleTimeout = time.Duration(viper.GetInt("server.session_idle_timeout")) * time.Second
+
+	// Requests outlive ctx so in-flight tool calls can finish during shutdown
+	requestCtx, cancelRequests := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelRequests()
+
+	mux := http.NewServeMux()
+	mux.Handle("/mcp", handler)
+	httpServer := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return requestCtx },
+	}
+	// Open SSE streams never finish on their own, so end them when
+	// shutdown starts rather than waiting them out
+	httpServer.RegisterOnShutdown(handler.Close)
 
-func handleGetWorkStatus(ctx context.Context, params json.RawMessage) (interface{}, error) {
-	return map[string]interface{}{
-		"work_id": "work_123456",
-		"status":  "running",
-		"progress": 50,
-		"message": "Work is currently running (Phase 1 placeholder)",
-	}, nil
-}
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- httpServer.Serve(listener)
+	}()
 
-func handleListNodes(ctx context.Context, params json.RawMessage) (interface{}, error) {
-	return map[string]interface{}{
-		"nodes": []map[string]interface{}{
-			{"id": "controller", "status": "connected", "type": "controller"},
-			{"id": "worker-01", "status": "connected", "type": "worker"},
-			{"id": "worker-02", "status": "connected", "type": "worker"},
-		},
-		"message": "Node list (Phase 1 placeholder)",
-	}, nil
-}
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+	}
 
-func handleGetNodeInfo(ctx context.Context, params json.RawMessage) (interface{}, error) {
-	return map[string]interface{}{
-		"node_id": "controller",
-		"status":  "connected",
-		"capabilities": []string{"work-command", "control-service"},
-		"connections": []string{"worker-01", "worker-02"},
-		"message": "Node info (Phase 1 placeholder)",
-	}, nil
-}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err == nil {
+		return nil
+	}
 
-func handleGetMeshStatus(ctx context.Context, params json.RawMessage) (interface{}, error) {
-	return map[string]interface{}{
-		"topology": "mesh",
-		"nodes":    3,
-		"connections": 2,
-		"health": "healthy",
-		"message": "Mesh status (Phase 1 placeholder)",
-	}, nil
+	// Cancelled requests get a moment to answer before connections close
+	log.Printf("In-flight HTTP requests still running after %v, cancelling them", shutdownTimeout)
+	cancelRequests()
+	graceCtx, cancelGrace := context.WithTimeout(context.Background(), cancelGracePeriod)
+	defer cancelGrace()
+	if err := httpServer.Shutdown(graceCtx); err != nil {
+		httpServer.Close()
+	}
+	return nil
 }
 
-func handleCancelWork(ctx context.Context, params json.RawMessage) (interface{}, error) {
-	return map[string]interface{}{
-		"work_id": "work_123456",
-		"status":  "cancelled",
-		"message": "Work cancelled successfully (Phase 1 placeholder)",
-	}, nil
+// httpListenConfig returns the address to listen on and the bearer token
+// clients must send, refusing to listen beyond the loopback interface
+// without a token
+func httpListenConfig(listen string) (string, string, error) {
+	listen, err := listenAddress(listen)
+	if err != nil {
+		return "", "", err
+	}
+	token, err := authToken()
+	if err != nil {
+		return "", "", err
+	}
+	if token == "" && !loopbackAddress(listen) {
+		return "", "", fmt.Errorf("refusing to serve MCP on %s without authentication: set server.auth_token or server.auth_token_file, or listen on 127.0.0.1", listen)
+	}
+	return listen, token, nil
 }
 
-func handleGetWorkResults(ctx context.Context, params json.RawMessage) (interface{}, error) {
-	return map[string]interface{}{
-		"work_id": "work_123456",
-		"status":  "completed",
-		"results": "Task completed successfully",
-		"logs":    "2024-01-01 12:00:00 Task started\n2024-01-01 12:01:00 Task completed",
-		"message": "Work results (Phase 1 placeholder)",
-	}, nil
+// listenAddress binds addresses without a host, such as ":8889", to the
+// loopback interface; other hosts must be given explicitly
+func listenAddress(listen string) (string, error) {
+	host, port, err := net.SplitHostPort(listen)
+	if err != nil {
+		return "", fmt.Errorf("invalid listen address %q: %w", listen, err)
+	}
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, port), nil
 }
 
-// Placeholder resource handlers
-func handleMeshTopologyResource(ctx context.Context, params json.RawMessage) (interface{}, error) {
-	content := mcp.ResourceContent{
-		URI:      "receptor://mesh/topology",
-		MimeType: "application/json",
-		Text:     `{"topology": "mesh", "nodes": ["controller", "worker-01", "worker-02"], "connections": [{"from": "controller", "to": "worker-01"}, {"from": "controller", "to": "worker-02"}]}`,
+// loopbackAddress reports whether a listen address only accepts local
+// connections
+func loopbackAddress(listen string) bool {
+	host, _, _ := net.SplitHostPort(listen)
+	if host == "localhost" {
+		return true
 	}
-	return mcp.ResourcesReadResponse{Contents: []mcp.ResourceContent{content}}, nil
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
 }
 
-func handleNodeStatusResource(ctx context.Context, params json.RawMessage) (interface{}, error) {
-	content := mcp.ResourceContent{
-		URI:      "receptor://nodes/status",
-		MimeType: "application/json",
-		Text:     `{"nodes": [{"id": "controller", "status": "connected", "load": 0.2}, {"id": "worker-01", "status": "connected", "load": 0.5}, {"id": "worker-02", "status": "connected", "load": 0.3}]}`,
+// authToken returns the bearer token HTTP clients must send, from
+// server.auth_token or the file named by server.auth_token_file
+func authToken() (string, error) {
+	file := viper.GetString("server.auth_token_file")
+	if file == "" {
+		return viper.GetString("server.auth_token"), nil
 	}
-	return mcp.ResourcesReadResponse{Contents: []mcp.ResourceContent{content}}, nil
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("reading auth token: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("auth token file %s is empty", file)
+	}
+	return token, nil
 }
 
-func handleWorkQueueResource(ctx context.Context, params json.RawMessage) (interface{}, error) {
-	content := mcp.ResourceContent{
-		URI:      "receptor://work/queue",
-		MimeType: "application/json",
-		Text:     `{"active": [{"work_id": "work_123456", "status": "running", "node_id": "worker-01"}], "pending": []}`,
+// newEndpoints builds the control service entry points from the
+// receptor.nodes list. "localhost" is the control service configured by
+// receptor.address or receptor.socket; other entries are Unix socket paths
+// (optionally prefixed with "unix:") or TLS host:port addresses sharing
+// the receptor.tls settings. Entries naming the same entry point twice are
+// only connected to once.
+func newEndpoints(nodes []string) ([]receptor.Endpoint, error) {
+	if len(nodes) == 0 {
+		nodes = []string{"localhost"}
 	}
-	return mcp.ResourcesReadResponse{Contents: []mcp.ResourceContent{content}}, nil
-}
 
-func handleWorkHistoryResource(ctx context.Context, params json.RawMessage) (interface{}, error) {
-	content := mcp.ResourceContent{
-		URI:      "receptor://work/history",
-		MimeType: "application/json",
-		Text:     `{"completed": [{"work_id": "work_123455", "status": "completed", "node_id": "worker-01", "duration": "30s"}], "failed": []}`,
+	var endpoints []receptor.Endpoint
+	for _, node := range nodes {
+		switch {
+		case strings.Contains(node, "://"):
+			return nil, fmt.Errorf("invalid receptor node %q: give a socket path or host:port without a scheme", node)
+		case node == "localhost":
+			if address := viper.GetString("receptor.address"); address != "" {
+				dialer, err := newTLSDialer(address)
+				if err != nil {
+					return nil, err
+				}
+				endpoints = append(endpoints, receptor.Endpoint{Name: address, Dialer: dialer})
+			} else {
+				socket := viper.GetString("receptor.socket")
+				endpoints = append(endpoints, receptor.Endpoint{Name: socket, Dialer: receptor.UnixDialer{Path: socket}})
+			}
+		case strings.HasPrefix(node, "unix:") || strings.HasPrefix(node, "/"):
+			socket := strings.TrimPrefix(node, "unix:")
+			endpoints = append(endpoints, receptor.Endpoint{Name: socket, Dialer: receptor.UnixDialer{Path: socket}})
+		default:
+			if _, _, err := net.SplitHostPort(node); err != nil {
+				return nil, fmt.Errorf("invalid receptor node %q: expected localhost, a socket path or host:port", node)
+			}
+			dialer, err := newTLSDialer(node)
+			if err != nil {
+				return nil, err
+			}
+			endpoints = append(endpoints, receptor.Endpoint{Name: node, Dialer: dialer})
+		}
 	}
-	return mcp.ResourcesReadResponse{Contents: []mcp.ResourceContent{content}}, nil
+	return uniqueEndpoints(endpoints), nil
 }
 
-// Placeholder prompt handlers
-func handleDeployWorkflowPrompt(ctx context.Context, params json.RawMessage) (interface{}, error) {
-	messages := []mcp.Message{
-		{
-			Role: "user",
-			Content: []mcp.Content{
-				{
-					Type: "text",
-					Text: "I need help deploying a workflow across my Receptor mesh. Here are the steps to consider:\n\n1. **Assess Current Mesh Status**: First, check the health and capacity of your mesh nodes\n2. **Define Workflow Requirements**: Specify the work types, dependencies, and resource requirements\n3. **Plan Node Distribution**: Choose optimal nodes based on capabilities and current load\n4. **Submit Work in Sequence**: Deploy workflow components in the correct order\n5. **Monitor Progress**: Track execution and handle any failures\n\nWhat type of workflow would you like to deploy?",
-				},
-			},
-		},
-	}
-	return mcp.PromptsGetResponse{
-		Description: "Workflow deployment guidance",
-		Messages:    messages,
-	}, nil
+// uniqueEndpoints drops entry points with the name of an earlier one
+func uniqueEndpoints(endpoints []receptor.Endpoint) []receptor.Endpoint {
+	seen := map[string]bool{}
+	unique := endpoints[:0]
+	for _, ep := range endpoints {
+		if !seen[ep.Name] {
+			seen[ep.Name] = true
+			unique = append(unique, ep)
+		}
+	}
+	return unique
 }
 
-func handleTroubleshootMeshPrompt(ctx context.Context, params json.RawMessage) (interface{}, error) {
-	messages := []mcp.Message{
-		{
-			Role: "user",
-			Content: []mcp.Content{
-				{
-					Type: "text",
-					Text: "Let's troubleshoot your Receptor mesh network. Common issues and solutions:\n\n**Connection Issues:**\n- Check network connectivity between nodes\n- Verify firewall rules and port accessibility\n- Confirm TLS certificates are valid\n\n**Performance Issues:**\n- Monitor node resource usage (CPU, memory)\n- Check work queue backlogs\n- Analyze network latency between nodes\n\n**Work Execution Problems:**\n- Verify work types are properly configured\n- Check node capabilities and permissions\n- Review work execution logs\n\nWhat specific issue are you experiencing?",
-				},
-			},
-		},
-	}
-	return mcp.PromptsGetResponse{
-		Description: "Mesh troubleshooting guidance",
-		Messages:    messages,
-	}, nil
+// newTLSDialer returns a TLS dialer for address using the receptor.tls settings
+func newTLSDialer(address string) (receptor.Dialer, error) {
+	dialer, err := receptor.NewTLSDialer(address, receptor.TLSOptions{
+		CertFile:           viper.GetString("receptor.tls.cert"),
+		KeyFile:            viper.GetString("receptor.tls.key"),
+		CAFile:             viper.GetString("receptor.tls.ca"),
+		ServerName:         viper.GetString("receptor.tls.server_name"),
+		InsecureSkipVerify: !viper.GetBool("receptor.tls_verify"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("configuring Receptor TLS connection to %s: %w", address, err)
+	}
+	return dialer, nil
 }
-
-func handleOptimizeWorkloadPrompt(ctx context.Context, params json.RawMessage) (interface{}, error) {
-	messages := []mcp.Message{
-		{
-			Role: "user",
-			Content: []mcp.Content{
-				{
-					Type: "text",
-					Text: "Here are strategies to optimize your Receptor workloads:\n\n**Load Balancing:**\n- Distribute work evenly across available nodes\n- Use node capabilities to match work types\n- Monitor and adjust based on node performance\n\n**Resource Optimization:**\n- Configure appropriate work concurrency limits\n- Optimize work payload sizes\n- Use work signing for security without performance impact\n\n**Network Efficiency:**\n- Minimize data transfer between nodes\n- Use local resources when possible\n- Consider edge nodes for geographically distributed work\n\n**Monitoring and Tuning:**\n- Track work execution times and success rates\n- Monitor resource utilization trends\n- Adjust timeout values based on work complexity\n\nWhat aspect of your workload would you like to optimize?",
-				},
-			},
-		},
-	}
-	return mcp.PromptsGetResponse{
-		Description: "Workload optimization recommendations",
-		Messages:    messages,
-	}, nil
-}
\ No newline at end of file
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ansible/receptor-mcp/pkg/mcp"
+	"github.com/ansible/receptor-mcp/pkg/receptor"
+	"github.com/spf13/viper"
+)
+
+// setConfig sets a configuration value for the duration of a test
+func setConfig(t *testing.T, key string, value interface{}) {
+	t.Helper()
+	previous := viper.Get(key)
+	viper.Set(key, value)
+	t.Cleanup(func() { viper.Set(key, previous) })
+}
+
+func TestNewEndpoints(t *testing.T) {
+	setConfig(t, "receptor.socket", "/run/receptor/receptor.sock")
+	setConfig(t, "receptor.address", "")
+
+	tests := []struct {
+		name  string
+		nodes []string
+		want  []string
+		tls   []bool
+	}{
+		{"empty", nil, []string{"/run/receptor/receptor.sock"}, []bool{false}},
+		{"localhost", []string{"localhost"}, []string{"/run/receptor/receptor.sock"}, []bool{false}},
+		{"unix", []string{"unix:/tmp/a.sock", "/tmp/b.sock"}, []string{"/tmp/a.sock", "/tmp/b.sock"}, []bool{false, false}},
+		{"tls", []string{"controller-b:2222", "[::1]:2222"}, []string{"controller-b:2222", "[::1]:2222"}, []bool{true, true}},
+		{"mixed", []string{"localhost", "controller-b:2222"}, []string{"/run/receptor/receptor.sock", "controller-b:2222"}, []bool{false, true}},
+		{"duplicates", []string{"localhost", "/run/receptor/receptor.sock", "unix:/run/receptor/receptor.sock", "b:2222", "b:2222"}, []string{"/run/receptor/receptor.sock", "b:2222"}, []bool{false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoints, err := newEndpoints(tt.nodes)
+			if err != nil {
+				t.Fatalf("newEndpoints returned error: %v", err)
+			}
+			var names []string
+			var tls []bool
+			for _, ep := range endpoints {
+				names = append(names, ep.Name)
+				_, isTLS := ep.Dialer.(*receptor.TLSDialer)
+				tls = append(tls, isTLS)
+			}
+			if !reflect.DeepEqual(names, tt.want) || !reflect.DeepEqual(tls, tt.tls) {
+				t.Errorf("Expected %v (TLS %v), got %v (TLS %v)", tt.want, tt.tls, names, tls)
+			}
+		})
+	}
+
+	for _, node := range []string{"controller-b", "tcp://controller-b:2222", "unix:///tmp/a.sock", ""} {
+		if _, err := newEndpoints([]string{node}); err == nil {
+			t.Errorf("Expected an error for %q", node)
+		}
+	}
+
+	// localhost is the TLS listener when receptor.address is set
+	setConfig(t, "receptor.address", "controller-a:2222")
+	endpoints, err := newEndpoints([]string{"localhost"})
+	if err != nil || len(endpoints) != 1 || endpoints[0].Name != "controller-a:2222" {
+		t.Errorf("Expected the TLS listener, got %v, %v", endpoints, err)
+	}
+}
+
+func TestHTTPListenConfig(t *testing.T) {
+	setConfig(t, "server.auth_token_file", "")
+	tests := []struct {
+		listen string
+		want   string
+		local  bool
+	}{
+		{":8889", "127.0.0.1:8889", true},
+		{"127.0.0.1:8889", "127.0.0.1:8889", true},
+		{"127.0.0.2:8889", "127.0.0.2:8889", true},
+		{"localhost:8889", "localhost:8889", true},
+		{"[::1]:8889", "[::1]:8889", true},
+		{"0.0.0.0:8889", "0.0.0.0:8889", false},
+		{"[::]:8889", "[::]:8889", false},
+		{"192.168.1.5:8889", "192.168.1.5:8889", false},
+		{"mcp.example.com:8889", "mcp.example.com:8889", false},
+		{"localhost.example.com:8889", "localhost.example.com:8889", false},
+	}
+	for _, tt := range tests {
+		for _, token := range []string{"", "secret"} {
+			setConfig(t, "server.auth_token", token)
+			listen, gotToken, err := httpListenConfig(tt.listen)
+			if !tt.local && token == "" {
+				if err == nil {
+					t.Errorf("%s: expected listening without a token to be refused", tt.listen)
+				}
+				continue
+			}
+			if err != nil || listen != tt.want || gotToken != token {
+				t.Errorf("%s with token %q: expected %s, got %s, %q, %v", tt.listen, token, tt.want, listen, gotToken, err)
+			}
+		}
+	}
+
+	setConfig(t, "server.auth_token", "")
+	if _, _, err := httpListenConfig("8889"); err == nil {
+		t.Error("Expected an error for an address without a port separator")
+	}
+}
+
+func TestAuthTokenFile(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "token"), []byte("secret\n"), 0o600)
+	os.WriteFile(filepath.Join(dir, "empty"), []byte(" \n"), 0o600)
+	// The file takes precedence over server.auth_token
+	setConfig(t, "server.auth_token", "ignored")
+
+	setConfig(t, "server.auth_token_file", filepath.Join(dir, "token"))
+	if token, err := authToken(); err != nil || token != "secret" {
+		t.Errorf("Expected the token from the file, got %q, %v", token, err)
+	}
+
+	for _, name := range []string{"empty", "missing"} {
+		setConfig(t, "server.auth_token_file", filepath.Join(dir, name))
+		if _, _, err := httpListenConfig("0.0.0.0:8889"); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+		if _, _, err := httpListenConfig(":8889"); err == nil {
+			t.Errorf("%s: expected an error even on loopback", name)
+		}
+	}
+}
+
+// postMCP POSTs a JSON-RPC request to an MCP HTTP endpoint and returns the
+// response body
+func postMCP(t *testing.T, url, sessionID, body string) (string, string) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if sessionID != "" {
+		req.Header.Set(mcp.SessionIDHeader, sessionID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Errorf("POST failed: %v", err)
+		return "", ""
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.Header.Get(mcp.SessionIDHeader), string(data)
+}
+
+func TestServeMCPHTTPShutdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		release time.Duration
+		want    string
+	}{
+		{"drains in-flight calls", 5 * time.Second, 200 * time.Millisecond, "finished"},
+		{"cancels calls after the timeout", 100 * time.Millisecond, time.Minute, "context canceled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started := make(chan struct{})
+			server := mcp.NewServer("test-server", "1.0.0")
+			server.RegisterTool(mcp.Tool{Name: "slow", InputSchema: map[string]interface{}{"type": "object"}},
+				func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+					close(started)
+					select {
+					case <-time.After(tt.release):
+						return "finished", nil
+					case <-ctx.Done():
+						return nil, ctx.Err()
+					}
+				})
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			url := "http://" + listener.Addr().String() + "/mcp"
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			served := make(chan error, 1)
+			go func() {
+				served <- serveMCPHTTP(ctx, server, listener, "", tt.timeout)
+			}()
+
+			sessionID, _ := postMCP(t, url, "", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"`+mcp.MCPVersion+`","clientInfo":{"name":"test","version":"1.0.0"}}}`)
+			if sessionID == "" {
+				t.Fatal("Expected initialize to assign a session ID")
+			}
+
+			// An open event stream must not hold up shutdown
+			streamReq, _ := http.NewRequest(http.MethodGet, url, nil)
+			streamReq.Header.Set("Accept", "text/event-stream")
+			streamReq.Header.Set(mcp.SessionIDHeader, sessionID)
+			stream, err := http.DefaultClient.Do(streamReq)
+			if err != nil {
+				t.Fatalf("GET failed: %v", err)
+			}
+			defer stream.Body.Close()
+
+			result := make(chan string, 1)
+			go func() {
+				_, body := postMCP(t, url, sessionID, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"slow","arguments":{}}}`)
+				result <- body
+			}()
+			<-started
+			cancel()
+
+			select {
+			case body := <-result:
+				if !strings.Contains(body, tt.want) {
+					t.Errorf("Expected the call to end with %q, got %s", tt.want, body)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("The in-flight call never finished")
+			}
+			select {
+			case err := <-served:
+				if err != nil {
+					t.Errorf("serveMCPHTTP returned error: %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("serveMCPHTTP did not return after shutdown")
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ansible/receptor-mcp/pkg/mcp"
+)
+
+// testMessage is a JSON-RPC message received from the server
+type testMessage struct {
+	ID     interface{}       `json:"id"`
+	Method string            `json:"method"`
+	Params json.RawMessage   `json:"params"`
+	Result json.RawMessage   `json:"result"`
+	Error  *mcp.JSONRPCError `json:"error"`
+}
+
+// mcpTestClient drives a running server over an in-memory pipe
+type mcpTestClient struct {
+	t         *testing.T
+	transport mcp.Transport
+	nextID    int
+}
+
+// startMCPClient runs server over a pipe and completes the initialize
+// handshake
+func startMCPClient(t *testing.T, server *mcp.Server) *mcpTestClient {
+	t.Helper()
+	client, transport := mcp.NewPipeTransport()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.Run(ctx, transport) }()
+	t.Cleanup(func() {
+		client.Close()
+		cancel()
+		<-done
+	})
+
+	c := &mcpTestClient{t: t, transport: client}
+	c.call("initialize", mcp.InitializeRequest{ProtocolVersion: mcp.MCPVersion})
+	c.send(mcp.JSONRPCNotification{JSONRPC: "2.0", Method: "notifications/initialized"})
+	return c
+}
+
+// send writes a message to the server
+func (c *mcpTestClient) send(msg interface{}) {
+	c.t.Helper()
+	data, _ := json.Marshal(msg)
+	if err := c.transport.WriteMessage(context.Background(), data); err != nil {
+		c.t.Fatalf("Failed to send message: %v", err)
+	}
+}
+
+// read returns the next message from the server
+func (c *mcpTestClient) read() testMessage {
+	c.t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	data, err := c.transport.ReadMessage(ctx)
+	if err != nil {
+		c.t.Fatalf("Failed to read message: %v", err)
+	}
+	var msg testMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		c.t.Fatalf("Failed to decode message %s: %v", data, err)
+	}
+	return msg
+}
+
+// call sends a request and decodes its result into out, failing the test
+// on an error response. Notifications arriving first are skipped.
+func (c *mcpTestClient) call(method string, params interface{}, out ...interface{}) {
+	c.t.Helper()
+	c.nextID++
+	c.send(mcp.JSONRPCRequest{JSONRPC: "2.0", ID: c.nextID, Method: method, Params: params})
+	for {
+		msg := c.read()
+		if msg.Method != "" {
+			continue
+		}
+		if msg.Error != nil {
+			c.t.Fatalf("%s failed: %+v", method, msg.Error)
+		}
+		if len(out) > 0 {
+			if err := json.Unmarshal(msg.Result, out[0]); err != nil {
+				c.t.Fatalf("Failed to decode %s result %s: %v", method, msg.Result, err)
+			}
+		}
+		return
+	}
+}
+
+// tools lists the server's tools by name
+func (c *mcpTestClient) tools() map[string]mcp.Tool {
+	c.t.Helper()
+	var list mcp.ToolsListResponse
+	c.call("tools/list", nil, &list)
+	tools := map[string]mcp.Tool{}
+	for _, tool := range list.Tools {
+		tools[tool.Name] = tool
+	}
+	return tools
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ansible/receptor-mcp/pkg/mcp"
+)
+
+// registerReceptorPrompts registers the 3 Receptor prompts defined in the design
+func registerReceptorPrompts(server *mcp.Server) {
+	// Prompt 1: deploy_workflow
+	server.RegisterPrompt(mcp.Prompt{
+		Name:        "deploy_workflow",
+		Description: "Guide for deploying complex workflows across the mesh",
+		Arguments: []mcp.PromptArgument{
+			{Name: "workflow_type", Description: "Type of workflow to deploy", Required: true},
+			{Name: "target_nodes", Description: "Target nodes for deployment", Required: false},
+		},
+	}, handleDeployWorkflowPrompt)
+
+	// Prompt 2: troubleshoot_mesh
+	server.RegisterPrompt(mcp.Prompt{
+		Name:        "troubleshoot_mesh",
+		Description: "Mesh network troubleshooting assistant",
+		Arguments: []mcp.PromptArgument{
+			{Name: "issue_type", Description: "Type of issue being experienced", Required: false},
+		},
+	}, handleTroubleshootMeshPrompt)
+
+	// Prompt 3: optimize_workload
+	server.RegisterPrompt(mcp.Prompt{
+		Name:        "optimize_workload",
+		Description: "Workload optimization recommendations",
+		Arguments: []mcp.PromptArgument{
+			{Name: "workload_pattern", Description: "Current workload pattern", Required: false},
+			{Name: "performance_goals", Description: "Performance optimization goals", Required: false},
+		},
+	}, handleOptimizeWorkloadPrompt)
+}
+
+// Prompt handlers
+func handleDeployWorkflowPrompt(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	messages := []mcp.Message{
+		{
+			Role: "user",
+			Content: []mcp.Content{
+				{
+					Type: "text",
+					Text: "I need help deploying a workflow across my Receptor mesh. Here are the steps to consider:\n\n1. **Assess Current Mesh Status**: First, check the health and capacity of your mesh nodes\n2. **Define Workflow Requirements**: Specify the work types, dependencies, and resource requirements\n3. **Plan Node Distribution**: Choose optimal nodes based on capabilities and current load\n4. **Submit Work in Sequence**: Deploy workflow components in the correct order\n5. **Monitor Progress**: Track execution and handle any failures\n\nWhat type of workflow would you like to deploy?",
+				},
+			},
+		},
+	}
+	return mcp.PromptsGetResponse{
+		Description: "Workflow deployment guidance",
+		Messages:    messages,
+	}, nil
+}
+
+func handleTroubleshootMeshPrompt(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	messages := []mcp.Message{
+		{
+			Role: "user",
+			Content: []mcp.Content{
+				{
+					Type: "text",
+					Text: "Let's troubleshoot your Receptor mesh network. Common issues and solutions:\n\n**Connection Issues:**\n- Check network connectivity between nodes\n- Verify firewall rules and port accessibility\n- Confirm TLS certificates are valid\n\n**Performance Issues:**\n- Monitor node resource usage (CPU, memory)\n- Check work queue backlogs\n- Analyze network latency between nodes\n\n**Work Execution Problems:**\n- Verify work types are properly configured\n- Check node capabilities and permissions\n- Review work execution logs\n\nWhat specific issue are you experiencing?",
+				},
+			},
+		},
+	}
+	return mcp.PromptsGetResponse{
+		Description: "Mesh troubleshooting guidance",
+		Messages:    messages,
+	}, nil
+}
+
+func handleOptimizeWorkloadPrompt(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	messages := []mcp.Message{
+		{
+			Role: "user",
+			Content: []mcp.Content{
+				{
+					Type: "text",
+					Text: "Here are strategies to optimize your Receptor workloads:\n\n**Load Balancing:**\n- Distribute work evenly across available nodes\n- Use node capabilities to match work types\n- Monitor and adjust based on node performance\n\n**Resource Optimization:**\n- Configure appropriate work concurrency limits\n- Optimize work payload sizes\n- Use work signing for security without performance impact\n\n**Network Efficiency:**\n- Minimize data transfer between nodes\n- Use local resources when possible\n- Consider edge nodes for geographically distributed work\n\n**Monitoring and Tuning:**\n- Track work execution times and success rates\n- Monitor resource utilization trends\n- Adjust timeout values based on work complexity\n\nWhat aspect of your workload would you like to optimize?",
+				},
+			},
+		},
+	}
+	return mcp.PromptsGetResponse{
+		Description: "Workload optimization recommendations",
+		Messages:    messages,
+	}, nil
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ansible/receptor-mcp/pkg/mcp"
+	"github.com/ansible/receptor-mcp/pkg/receptor"
+)
+
+// reapInterval is how often the reaper looks for work to release
+const reapInterval = time.Minute
+
+type releaseArgs struct {
+	WorkID string `json:"work_id" description:"Work ID returned from submit_work" required:"true"`
+	Force  bool   `json:"force" description:"Release the work even if it is still pending or running, cancelling it"`
+}
+
+type cleanupArgs struct {
+	OlderThan string `json:"older_than" description:"Only release work that finished longer ago than this duration (e.g. 24h, 90m). Receptor keeps no timestamps, so only work submitted through this server can match."`
+	State     string `json:"state" enum:"Succeeded,Failed,Canceled" description:"Only release work in this final state"`
+	WorkType  string `json:"work_type" description:"Only release work of this work type"`
+	NodeID    string `json:"node_id" description:"Only release work that ran on this node"`
+	DryRun    bool   `json:"dry_run" description:"List the work that would be released without releasing it"`
+	All       bool   `json:"all" description:"Also release work not submitted through this server, such as jobs from AWX or receptorctl"`
+}
+
+// cleanupEntry is a work unit matched by cleanup_work
+type cleanupEntry struct {
+	WorkID     string     `json:"work_id"`
+	Status     string     `json:"status"`
+	WorkType   string     `json:"work_type"`
+	NodeID     string     `json:"node_id,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// registerReleaseTools registers the tools that release work units,
+// deleting their files from the nodes
+func (h *receptorHandlers) registerReleaseTools(server *mcp.Server) {
+	mcp.RegisterTypedTool(server, mcp.Tool{
+		Name:        "release_work",
+		Description: "Release finished work, deleting its output and files from the mesh",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Release work",
+			DestructiveHint: hint(true),
+			IdempotentHint:  hint(false),
+			OpenWorldHint:   hint(false),
+		},
+	}, h.handleReleaseWork)
+
+	mcp.RegisterTypedTool(server, mcp.Tool{
+		Name:        "cleanup_work",
+		Description: "Release finished work submitted through this server matching filters, or preview it with dry_run. Work submitted by others is only released with all.",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Clean up work",
+			DestructiveHint: hint(true),
+			IdempotentHint:  hint(true),
+			OpenWorldHint:   hint(false),
+		},
+	}, h.handleCleanupWork)
+}
+
+func (h *receptorHandlers) handleReleaseWork(ctx context.Context, args releaseArgs) (map[string]interface{}, error) {
+	unitID := args.WorkID
+
+	client, status, err := h.pool.FindWork(ctx, unitID)
+	if err != nil {
+		return nil, err
+	}
+	if !status.State.Final() && !args.Force {
+		return nil, fmt.Errorf("work %s is %s; cancel it first, or set force to release it anyway", unitID, status.State)
+	}
+
+	if err := client.ReleaseWork(ctx, unitID); err != nil {
+		return nil, err
+	}
+	h.recordStatus(unitID, status)
+	h.recordReleased(unitID)
+
+	return map[string]interface{}{
+		"work_id":      unitID,
+		"status":       "released",
+		"final_status": status.State.String(),
+	}, nil
+}
+
+func (h *receptorHandlers) handleCleanupWork(ctx context.Context, args cleanupArgs) (map[string]interface{}, error) {
+	var olderThan time.Duration
+	if args.OlderThan != "" {
+		var err error
+		if olderThan, err = time.ParseDuration(args.OlderThan); err != nil || olderThan <= 0 {
+			return nil, fmt.Errorf("invalid older_than %q: expected a positive duration such as 24h", args.OlderThan)
+		}
+	}
+
+	units, err := h.pool.ListWork(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	matched := []cleanupEntry{}
+	for id, status := range units {
+		if !status.State.Final() || (args.State != "" && status.State.String() != args.State) {
+			continue
+		}
+		if _, tracked := h.store.Get(id); !tracked && !args.All {
+			continue
+		}
+		h.recordStatus(id, &status)
+		entry := h.cleanupCandidate(id, &status)
+		if (args.WorkType != "" && entry.WorkType != args.WorkType) || (args.NodeID != "" && entry.NodeID != args.NodeID) {
+			continue
+		}
+		if olderThan > 0 && (entry.FinishedAt == nil || now.Sub(*entry.FinishedAt) < olderThan) {
+			continue
+		}
+		matched = append(matched, entry)
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].WorkID < matched[j].WorkID })
+
+	if args.DryRun {
+		return map[string]interface{}{
+			"dry_run":       true,
+			"would_release": matched,
+		}, nil
+	}
+
+	released := []cleanupEntry{}
+	failed := []cleanupEntry{}
+	for i, entry := range matched {
+		if err := h.releaseUnit(ctx, entry.WorkID); err != nil {
+			entry.Error = err.Error()
+			failed = append(failed, entry)
+		} else {
+			released = append(released, entry)
+		}
+		mcp.ReportProgress(ctx, float64(i+1), float64(len(matched)), fmt.Sprintf("Released %d of %d work units", len(released), len(matched)))
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	return map[string]interface{}{
+		"released": released,
+		"failed":   failed,
+	}, nil
+}
+
+// cleanupCandidate describes a finished unit for cleanup_work. Work type and
+// node come from the work store for tracked units, otherwise from the
+// unit's remote details; the node of an untracked local unit is unknown.
+func (h *receptorHandlers) cleanupCandidate(unitID string, status *receptor.WorkStatus) cleanupEntry {
+	entry := cleanupEntry{WorkID: unitID, Status: status.State.String(), WorkType: status.WorkType}
+	if remote := status.Remote(); remote != nil {
+		entry.WorkType, entry.NodeID = remote.RemoteWorkType, remote.RemoteNode
+	}
+	if record, ok := h.store.Get(unitID); ok {
+		entry.WorkType, entry.NodeID = record.WorkType, record.Node
+		entry.FinishedAt = record.FinishedAt
+	}
+	return entry
+}
+
+// releaseUnit releases a work unit from whichever entry point owns it
+func (h *receptorHandlers) releaseUnit(ctx context.Context, unitID string) error {
+	client, _, err := h.pool.FindWork(ctx, unitID)
+	if err != nil {
+		return err
+	}
+	if err := client.ReleaseWork(ctx, unitID); err != nil {
+		return err
+	}
+	h.recordReleased(unitID)
+	return nil
+}
+
+// reapWork releases tracked units that finished more than maxAge ago and
+// whose output has been fetched, until ctx is done
+func (h *receptorHandlers) reapWork(ctx context.Context, maxAge time.Duration) {
+	ticker := time.NewTicker(reapInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		h.reap(ctx, maxAge)
+	}
+}
+
+// reap releases the tracked units that finished more than maxAge ago and
+// whose output has been fetched
+func (h *receptorHandlers) reap(ctx context.Context, maxAge time.Duration) {
+	for _, record := range h.store.History() {
+		if record.Released() || record.FetchedAt == nil || time.Since(*record.FinishedAt) < maxAge {
+			continue
+		}
+		err := h.releaseUnit(ctx, record.UnitID)
+		var controlErr *receptor.ControlError
+		switch {
+		case err == nil:
+			h.logger.Infof("Released work %s, finished %s ago", record.UnitID, time.Since(*record.FinishedAt).Round(time.Second))
+		case errors.As(err, &controlErr) && h.allHealthy():
+			// Released by someone else
+			h.recordReleased(record.UnitID)
+		default:
+			h.logger.Warningf("Releasing work %s failed: %v", record.UnitID, err)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
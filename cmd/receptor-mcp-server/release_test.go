@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ansible/receptor-mcp/pkg/receptor"
+	"github.com/ansible/receptor-mcp/pkg/workstore"
+)
+
+// trackedOutput is the stdout of units added by trackUnit
+const trackedOutput = "one\ntwo\n"
+
+// trackUnit adds a unit to the fake and, unless node is empty, to the work
+// store as submitted through the server, finished finishedAgo ago
+func trackUnit(t *testing.T, h *receptorHandlers, fake *fakeControl, unitID, node, workType string, state receptor.WorkState, finishedAgo time.Duration, fetched bool) {
+	t.Helper()
+	fake.addUnit(unitID, receptor.WorkStatus{State: state, WorkType: workType}, trackedOutput)
+	if node == "" {
+		return
+	}
+
+	finishedAt := time.Now().UTC().Add(-finishedAgo)
+	record := workstore.Record{
+		UnitID:      unitID,
+		Node:        node,
+		WorkType:    workType,
+		SubmittedAt: finishedAt.Add(-time.Minute),
+		UpdatedAt:   finishedAt,
+		State:       state.String(),
+		StdoutSize:  int64(len(trackedOutput)),
+	}
+	if state.Final() {
+		record.FinishedAt = &finishedAt
+	}
+	if fetched {
+		record.FetchedAt = &finishedAt
+	}
+	if err := h.store.Put(record); err != nil {
+		t.Fatalf("Put returned error: %v", err)
+	}
+}
+
+func cleanupIDs(entries []cleanupEntry) []string {
+	ids := []string{}
+	for _, entry := range entries {
+		ids = append(ids, entry.WorkID)
+	}
+	return ids
+}
+
+func TestCleanupWorkFilters(t *testing.T) {
+	fake := newFakeControl(t, "controller")
+	h := newTestHandlers(t, fake)
+	trackUnit(t, h, fake, "unitA", "worker-01", "echo", receptor.WorkStateSucceeded, 48*time.Hour, false)
+	trackUnit(t, h, fake, "unitB", "worker-02", "echo", receptor.WorkStateFailed, time.Hour, false)
+	trackUnit(t, h, fake, "unitC", "worker-01", "sleep", receptor.WorkStateSucceeded, time.Hour, false)
+	trackUnit(t, h, fake, "unitD", "worker-01", "echo", receptor.WorkStateRunning, 0, false)
+	// Submitted by someone else, such as AWX
+	trackUnit(t, h, fake, "unitE", "", "echo", receptor.WorkStateSucceeded, 0, false)
+
+	tests := []struct {
+		name string
+		args cleanupArgs
+		want []string
+	}{
+		{"no filters", cleanupArgs{}, []string{"unitA", "unitB", "unitC"}},
+		{"state", cleanupArgs{State: "Failed"}, []string{"unitB"}},
+		{"work type", cleanupArgs{WorkType: "sleep"}, []string{"unitC"}},
+		{"node", cleanupArgs{NodeID: "worker-01"}, []string{"unitA", "unitC"}},
+		{"age", cleanupArgs{OlderThan: "24h"}, []string{"unitA"}},
+		{"all", cleanupArgs{All: true}, []string{"unitA", "unitB", "unitC", "unitE"}},
+		{"combined", cleanupArgs{NodeID: "worker-01", State: "Succeeded", OlderThan: "2h"}, []string{"unitA"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args.DryRun = true
+			result, err := h.handleCleanupWork(context.Background(), tt.args)
+			if err != nil {
+				t.Fatalf("handleCleanupWork returned error: %v", err)
+			}
+			if got := cleanupIDs(result["would_release"].([]cleanupEntry)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	// Dry runs release nothing
+	for _, id := range []string{"unitA", "unitB", "unitC", "unitD", "unitE"} {
+		if fake.released(id) {
+			t.Fatalf("Expected dry runs to leave %s alone", id)
+		}
+	}
+
+	result, err := h.handleCleanupWork(context.Background(), cleanupArgs{State: "Succeeded"})
+	if err != nil {
+		t.Fatalf("handleCleanupWork returned error: %v", err)
+	}
+	if got := cleanupIDs(result["released"].([]cleanupEntry)); !reflect.DeepEqual(got, []string{"unitA", "unitC"}) {
+		t.Errorf("Expected unitA and unitC to be released, got %v", got)
+	}
+	if !fake.released("unitA") || fake.released("unitB") || fake.released("unitE") {
+		t.Error("Expected only the matching tracked units to be released")
+	}
+	if record, _ := h.store.Get("unitA"); !record.Released() {
+		t.Error("Expected the release to be recorded")
+	}
+
+	if _, err := h.handleCleanupWork(context.Background(), cleanupArgs{OlderThan: "yesterday"}); err == nil {
+		t.Error("Expected an error for an invalid older_than")
+	}
+}
+
+func TestReapWork(t *testing.T) {
+	fake := newFakeControl(t, "controller")
+	h := newTestHandlers(t, fake)
+	trackUnit(t, h, fake, "old", "worker-01", "echo", receptor.WorkStateSucceeded, 2*time.Hour, true)
+	trackUnit(t, h, fake, "unfetched", "worker-01", "echo", receptor.WorkStateSucceeded, 2*time.Hour, false)
+	trackUnit(t, h, fake, "recent", "worker-01", "echo", receptor.WorkStateSucceeded, time.Minute, true)
+	trackUnit(t, h, fake, "gone", "worker-01", "echo", receptor.WorkStateFailed, 2*time.Hour, true)
+	// Released behind the server's back
+	fake.mu.Lock()
+	delete(fake.units, "gone")
+	fake.mu.Unlock()
+
+	h.reap(context.Background(), time.Hour)
+
+	want := map[string]bool{"old": true, "unfetched": false, "recent": false, "gone": true}
+	for id, released := range want {
+		if fake.released(id) != released {
+			t.Errorf("%s: expected released=%t on the mesh", id, released)
+		}
+		if record, _ := h.store.Get(id); record.Released() != released {
+			t.Errorf("%s: expected released=%t in the work store", id, released)
+		}
+	}
+}
+
+func TestRecordFetchedNeedsTheWholeOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    getWorkResultsArgs
+		fetched bool
+	}{
+		{"start", getWorkResultsArgs{Limit: 5}, false},
+		{"tail", getWorkResultsArgs{Tail: 1}, false},
+		{"rest", getWorkResultsArgs{Offset: 5}, true},
+		{"everything", getWorkResultsArgs{}, true},
+		{"tail of everything", getWorkResultsArgs{Tail: 10}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeControl(t, "controller")
+			h := newTestHandlers(t, fake)
+			trackUnit(t, h, fake, "unitA", "worker-01", "echo", receptor.WorkStateSucceeded, time.Hour, false)
+
+			tt.args.WorkID = "unitA"
+			if _, err := h.handleGetWorkResults(context.Background(), tt.args); err != nil {
+				t.Fatalf("handleGetWorkResults returned error: %v", err)
+			}
+			if record, _ := h.store.Get("unitA"); (record.FetchedAt != nil) != tt.fetched {
+				t.Errorf("Expected fetched=%t, got %+v", tt.fetched, record)
+			}
+		})
+	}
+}
+
+func TestReleaseWork(t *testing.T) {
+	fake := newFakeControl(t, "controller")
+	h := newTestHandlers(t, fake)
+	trackUnit(t, h, fake, "finished", "worker-01", "echo", receptor.WorkStateFailed, time.Hour, false)
+	trackUnit(t, h, fake, "running", "worker-01", "echo", receptor.WorkStateRunning, 0, false)
+
+	result, err := h.handleReleaseWork(context.Background(), releaseArgs{WorkID: "finished"})
+	if err != nil {
+		t.Fatalf("handleReleaseWork returned error: %v", err)
+	}
+	if result["status"] != "released" || result["final_status"] != "Failed" {
+		t.Errorf("Unexpected result %v", result)
+	}
+	if record, _ := h.store.Get("finished"); !fake.released("finished") || !record.Released() || record.State != "Failed" {
+		t.Errorf("Expected the release to reach the mesh and the store, got %+v", record)
+	}
+
+	// Running work is only released with force
+	if _, err := h.handleReleaseWork(context.Background(), releaseArgs{WorkID: "running"}); err == nil || fake.released("running") {
+		t.Fatalf("Expected running work to be refused, got %v", err)
+	}
+	if _, err := h.handleReleaseWork(context.Background(), releaseArgs{WorkID: "running", Force: true}); err != nil {
+		t.Fatalf("handleReleaseWork with force returned error: %v", err)
+	}
+	if record, _ := h.store.Get("running"); !fake.released("running") || !record.Released() || record.State != releasedState {
+		t.Errorf("Expected forced release to be recorded as released unfinished, got %+v", record)
+	}
+
+	if _, err := h.handleReleaseWork(context.Background(), releaseArgs{WorkID: "missing"}); err == nil {
+		t.Error("Expected an error for an unknown unit")
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"sort"
+
+	"github.com/ansible/receptor-mcp/pkg/mcp"
+	"github.com/ansible/receptor-mcp/pkg/receptor"
+)
+
+// registerReceptorResources registers the 4 Receptor resources defined in
+// the design, and templates for individual nodes and work units
+func (h *receptorHandlers) registerReceptorResources(server *mcp.Server) {
+	// Resource 1: mesh_topology
+	server.RegisterResource(mcp.Resource{
+		URI:         "receptor://mesh/topology",
+		Name:        "Mesh Topology",
+		Description: "Real-time mesh network topology information",
+		MimeType:    "application/json",
+	}, h.handleMeshTopologyResource)
+
+	// Resource 2: node_status
+	server.RegisterResource(mcp.Resource{
+		URI:         "receptor://nodes/status",
+		Name:        "Node Status",
+		Description: "Current status of all nodes in the mesh",
+		MimeType:    "application/json",
+	}, h.handleNodeStatusResource)
+
+	// Resource 3: work_queue
+	server.RegisterResource(mcp.Resource{
+		URI:         "receptor://work/queue",
+		Name:        "Work Queue",
+		Description: "Active and pending work submitted through this server",
+		MimeType:    "application/json",
+	}, h.handleWorkQueueResource)
+
+	// Resource 4: work_history
+	server.RegisterResource(mcp.Resource{
+		URI:         "receptor://work/history",
+		Name:        "Work History",
+		Description: "Finished work submitted through this server, most recent first",
+		MimeType:    "application/json",
+	}, h.handleWorkHistoryResource)
+
+	// Templates for individual nodes and work units
+	templates := []struct {
+		template mcp.ResourceTemplate
+		handler  mcp.ResourceTemplateHandler
+	}{
+		{mcp.ResourceTemplate{
+			URITemplate: "receptor://nodes/{node_id}",
+			Name:        "Node",
+			Description: "Details of a single mesh node",
+			MimeType:    "application/json",
+		}, h.handleNodeResource},
+		{mcp.ResourceTemplate{
+			URITemplate: "receptor://work/{unit_id}/status",
+			Name:        "Work Unit Status",
+			Description: "Status of a single work unit",
+			MimeType:    "application/json",
+		}, h.handleWorkStatusResource},
+		{mcp.ResourceTemplate{
+			URITemplate: "receptor://work/{unit_id}/stdout",
+			Name:        "Work Unit Output",
+			Description: "Standard output of a single work unit, up to the first 1 MiB",
+			MimeType:    "text/plain",
+		}, h.handleWorkStdoutResource},
+		{mcp.ResourceTemplate{
+			URITemplate: "receptor://work/{unit_id}/stdout/{chunk}",
+			Name:        "Work Unit Output Chunk",
+			Description: "One 256 KiB chunk of a work unit's standard output, numbered from 0",
+			MimeType:    "text/plain",
+		}, h.handleWorkStdoutChunkResource},
+	}
+	for _, t := range templates {
+		if err := server.RegisterResourceTemplate(t.template, t.handler); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// jsonResource renders v as the JSON contents of a resource
+func jsonResource(uri string, v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	content := mcp.ResourceContent{
+		URI:      uri,
+		MimeType: "application/json",
+		Text:     string(data),
+	}
+	return mcp.ResourcesReadResponse{Contents: []mcp.ResourceContent{content}}, nil
+}
+
+// workStdoutURI is the resource URI of a work unit's stdout
+func workStdoutURI(unitID string) string {
+	return "receptor://work/" + url.PathEscape(unitID) + "/stdout"
+}
+
+// workUnitEntry is a work unit as currently listed by the mesh
+type workUnitEntry struct {
+	WorkID     string `json:"work_id"`
+	Status     string `json:"status"`
+	WorkType   string `json:"work_type"`
+	Detail     string `json:"detail,omitempty"`
+	StdoutSize int64  `json:"stdout_size"`
+}
+
+// listWorkUnits returns the node's work units split into unfinished and
+// finished, each sorted by unit ID
+func (h *receptorHandlers) listWorkUnits(ctx context.Context) (active, finished []workUnitEntry, err error) {
+	units, err := h.pool.ListWork(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	active, finished = []workUnitEntry{}, []workUnitEntry{}
+	for id, status := range units {
+		entry := workUnitEntry{
+			WorkID:     id,
+			Status:     status.State.String(),
+			WorkType:   status.WorkType,
+			Detail:     status.Detail,
+			StdoutSize: status.StdoutSize,
+		}
+		if status.State.Final() {
+			finished = append(finished, entry)
+		} else {
+			active = append(active, entry)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].WorkID < active[j].WorkID })
+	sort.Slice(finished, func(i, j int) bool { return finished[i].WorkID < finished[j].WorkID })
+	return active, finished, nil
+}
+
+// Resource handlers
+func (h *receptorHandlers) handleMeshTopologyResource(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	status, err := h.primaryStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := status.Nodes()
+	sort.Strings(nodes)
+
+	type link struct {
+		From string  `json:"from"`
+		To   string  `json:"to"`
+		Cost float64 `json:"cost"`
+	}
+	connections := []link{}
+	for from, peers := range status.KnownConnectionCosts {
+		for to, cost := range peers {
+			if from < to {
+				connections = append(connections, link{From: from, To: to, Cost: cost})
+			}
+		}
+	}
+	sort.Slice(connections, func(i, j int) bool {
+		if connections[i].From != connections[j].From {
+			return connections[i].From < connections[j].From
+		}
+		return connections[i].To < connections[j].To
+	})
+
+	return jsonResource("receptor://mesh/topology", map[string]interface{}{
+		"controller":    status.NodeID,
+		"nodes":         nodes,
+		"connections":   connections,
+		"routing_table": status.RoutingTable,
+	})
+}
+
+func (h *receptorHandlers) handleNodeStatusResource(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	status, err := h.primaryStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := status.Nodes()
+	sort.Strings(ids)
+
+	nodes := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		nodes = append(nodes, map[string]interface{}{
+			"id":        id,
+			"reachable": id == status.NodeID || status.RoutingTable[id] != "",
+			"worktypes": nodeWorkTypes(status, id),
+		})
+	}
+
+	return jsonResource("receptor://nodes/status", map[string]interface{}{"nodes": nodes})
+}
+
+func (h *receptorHandlers) handleWorkQueueResource(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	pending := []workRecordEntry{}
+	running := []workRecordEntry{}
+	for _, record := range h.store.Active() {
+		if record.State == receptor.WorkStatePending.String() {
+			pending = append(pending, newWorkRecordEntry(record))
+		} else {
+			running = append(running, newWorkRecordEntry(record))
+		}
+	}
+
+	return jsonResource("receptor://work/queue", map[string]interface{}{
+		"active":  running,
+		"pending": pending,
+	})
+}
+
+func (h *receptorHandlers) handleWorkHistoryResource(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	completed := []workRecordEntry{}
+	failed := []workRecordEntry{}
+	for _, record := range h.store.History() {
+		if record.State == receptor.WorkStateSucceeded.String() {
+			completed = append(completed, newWorkRecordEntry(record))
+		} else {
+			failed = append(failed, newWorkRecordEntry(record))
+		}
+	}
+
+	return jsonResource("receptor://work/history", map[string]interface{}{
+		"completed": completed,
+		"failed":    failed,
+	})
+}
+
+// Resource template handlers
+func (h *receptorHandlers) handleNodeResource(ctx context.Context, uri string, vars map[string]string) (interface{}, error) {
+	info, err := h.nodeInfo(ctx, vars["node_id"])
+	if err != nil {
+		return nil, err
+	}
+	return jsonResource(uri, info)
+}
+
+func (h *receptorHandlers) handleWorkStatusResource(ctx context.Context, uri string, vars map[string]string) (interface{}, error) {
+	unitID := vars["unit_id"]
+	_, status, err := h.pool.FindWork(ctx, unitID)
+	if err != nil {
+		return nil, err
+	}
+	return jsonResource(uri, workStatusMap(unitID, status))
+}
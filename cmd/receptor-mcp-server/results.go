@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/ansible/receptor-mcp/pkg/mcp"
+	"github.com/ansible/receptor-mcp/pkg/receptor"
+)
+
+// maxResultBytes caps how much work output get_work_results returns inline
+const maxResultBytes = 1 << 20
+
+// stdoutChunkSize is the size of each receptor://work/{unit_id}/stdout/{chunk}
+// resource
+const stdoutChunkSize = 256 << 10
+
+// tailWindow is how much output a tail read starts with; it doubles until
+// enough lines are found or maxResultBytes is reached
+const tailWindow = 64 << 10
+
+type getWorkResultsArgs struct {
+	WorkID string `json:"work_id" description:"Work ID returned from submit_work" required:"true"`
+	Offset int64  `json:"offset" description:"Byte offset in stdout to start reading from"`
+	Limit  int64  `json:"limit" description:"Maximum number of bytes to return (default and maximum 1 MiB)"`
+	Tail   int    `json:"tail" description:"Return the last N lines of stdout instead of a byte range"`
+	Follow bool   `json:"follow" description:"Wait for the work to finish, reporting progress, before reading its output"`
+}
+
+// handleGetWorkResults returns a range of a work unit's stdout. Output of
+// unfinished units is read as far as it has been written, unless follow
+// waits for the unit to finish first. Only the requested range is read
+// from the control service.
+func (h *receptorHandlers) handleGetWorkResults(ctx context.Context, args getWorkResultsArgs) ([]mcp.Content, error) {
+	unitID := args.WorkID
+	if args.Offset < 0 || args.Limit < 0 || args.Tail < 0 {
+		return nil, fmt.Errorf("offset, limit and tail must not be negative")
+	}
+	if args.Tail > 0 && args.Offset > 0 {
+		return nil, fmt.Errorf("tail cannot be combined with offset")
+	}
+	limit := args.Limit
+	if limit == 0 || limit > maxResultBytes {
+		limit = maxResultBytes
+	}
+
+	client, status, err := h.pool.FindWork(ctx, unitID)
+	if err != nil {
+		return nil, err
+	}
+	if args.Follow && !status.State.Final() {
+		if status, err = waitForWork(ctx, client, unitID); err != nil {
+			return nil, err
+		}
+	}
+
+	offset := args.Offset
+	var data []byte
+	if args.Tail > 0 {
+		offset, data, err = tailStdout(ctx, client, unitID, status.StdoutSize, args.Tail, limit)
+	} else {
+		data, err = readStdout(ctx, client, unitID, offset, min(limit, status.StdoutSize-offset))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Paging through the output ends with a read reaching its end, but a
+	// tail only counts as fetched when it holds all of the output
+	end := offset + int64(len(data))
+	if args.Tail == 0 || offset == 0 {
+		h.recordFetched(ctx, unitID, status, end)
+	}
+
+	// Status and position as JSON text, with the output embedded as the
+	// work unit's stdout resource
+	result := workStatusMap(unitID, status)
+	result["offset"] = offset
+	result["length"] = len(data)
+	result["next_offset"] = end
+	result["truncated"] = end < status.StdoutSize
+	result["stdout_chunk_uri"] = workStdoutURI(unitID) + "/{chunk}"
+	result["stdout_chunk_size"] = stdoutChunkSize
+	summary, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.Content{
+		mcp.TextContent(string(summary)),
+		mcp.EmbeddedResourceContent(mcp.ResourceContent{
+			URI:      workStdoutURI(unitID),
+			MimeType: "text/plain",
+			Text:     string(data),
+		}),
+	}, nil
+}
+
+// readStdout reads length bytes of a work unit's stdout starting at offset.
+// The stream of a running unit stays open for more output, so callers must
+// not read past the unit's current stdout size.
+func readStdout(ctx context.Context, client *receptor.Client, unitID string, offset, length int64) ([]byte, error) {
+	if length <= 0 {
+		return nil, nil
+	}
+	reader, err := client.WorkResults(ctx, unitID, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, length))
+	if err != nil {
+		return nil, fmt.Errorf("reading results for %s: %w", unitID, err)
+	}
+	return data, nil
+}
+
+// tailStdout reads the last lines of the first size bytes of a work unit's
+// stdout, returning their offset. It reads a growing window from the end,
+// never more than limit bytes, so the first line may be cut short when
+// lines are very long.
+func tailStdout(ctx context.Context, client *receptor.Client, unitID string, size int64, lines int, limit int64) (int64, []byte, error) {
+	window := min(int64(tailWindow), limit)
+	for {
+		start := max(0, size-window)
+		data, err := readStdout(ctx, client, unitID, start, size-start)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		// A final newline ends the last line rather than starting another
+		found := 0
+		for i := len(bytes.TrimSuffix(data, []byte("\n"))) - 1; i >= 0; i-- {
+			if data[i] == '\n' {
+				found++
+				if found == lines {
+					return start + int64(i) + 1, data[i+1:], nil
+				}
+			}
+		}
+		if start == 0 || window == limit {
+			return start, data, nil
+		}
+		window = min(window*2, limit)
+	}
+}
+
+func (h *receptorHandlers) handleWorkStdoutResource(ctx context.Context, uri string, vars map[string]string) (interface{}, error) {
+	unitID := vars["unit_id"]
+	client, status, err := h.pool.FindWork(ctx, unitID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read only the output produced so far, so a running unit does not block
+	data, err := readStdout(ctx, client, unitID, 0, min(status.StdoutSize, maxResultBytes))
+	if err != nil {
+		return nil, err
+	}
+	h.recordFetched(ctx, unitID, status, int64(len(data)))
+
+	content := mcp.ResourceContent{
+		URI:      uri,
+		MimeType: "text/plain",
+		Text:     string(data),
+	}
+	return mcp.ResourcesReadResponse{Contents: []mcp.ResourceContent{content}}, nil
+}
+
+// handleWorkStdoutChunkResource reads one stdoutChunkSize chunk of a work
+// unit's stdout, so clients can page through output of any size
+func (h *receptorHandlers) handleWorkStdoutChunkResource(ctx context.Context, uri string, vars map[string]string) (interface{}, error) {
+	unitID := vars["unit_id"]
+	chunk, err := strconv.ParseInt(vars["chunk"], 10, 64)
+	if err != nil || chunk < 0 {
+		return nil, fmt.Errorf("invalid stdout chunk %q", vars["chunk"])
+	}
+
+	client, status, err := h.pool.FindWork(ctx, unitID)
+	if err != nil {
+		return nil, err
+	}
+	offset := chunk * stdoutChunkSize
+	if chunk > 0 && offset >= status.StdoutSize {
+		return nil, fmt.Errorf("chunk %d is past the %d bytes of stdout of work %s", chunk, status.StdoutSize, unitID)
+	}
+
+	data, err := readStdout(ctx, client, unitID, offset, min(stdoutChunkSize, status.StdoutSize-offset))
+	if err != nil {
+		return nil, err
+	}
+	h.recordFetched(ctx, unitID, status, offset+int64(len(data)))
+
+	content := mcp.ResourceContent{
+		URI:      uri,
+		MimeType: "text/plain",
+		Text:     string(data),
+	}
+	return mcp.ResourcesReadResponse{Contents: []mcp.ResourceContent{content}}, nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ansible/receptor-mcp/pkg/mcp"
+	"github.com/ansible/receptor-mcp/pkg/receptor"
+)
+
+// numberedLines returns n lines "line 1\n" to "line n\n"
+func numberedLines(n int) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	return b.String()
+}
+
+// decodeResults splits a get_work_results reply into its summary and output
+func decodeResults(t *testing.T, content []mcp.Content) (map[string]interface{}, string) {
+	t.Helper()
+	var summary map[string]interface{}
+	if err := json.Unmarshal([]byte(content[0].Text), &summary); err != nil {
+		t.Fatalf("Invalid summary %q: %v", content[0].Text, err)
+	}
+	return summary, content[1].Resource.Text
+}
+
+func TestGetWorkResultsRanges(t *testing.T) {
+	output := "0123456789"
+	fake := newFakeControl(t, "controller")
+	fake.addUnit("unitA", receptor.WorkStatus{State: receptor.WorkStateSucceeded}, output)
+	h := newTestHandlers(t, fake)
+
+	tests := []struct {
+		name      string
+		args      getWorkResultsArgs
+		want      string
+		offset    float64
+		truncated bool
+	}{
+		{"everything", getWorkResultsArgs{}, output, 0, false},
+		{"range", getWorkResultsArgs{Offset: 2, Limit: 3}, "234", 2, true},
+		{"limit past the end", getWorkResultsArgs{Offset: 8, Limit: 100}, "89", 8, false},
+		{"offset at the end", getWorkResultsArgs{Offset: 10}, "", 10, false},
+		{"offset past the end", getWorkResultsArgs{Offset: 50}, "", 50, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args.WorkID = "unitA"
+			content, err := h.handleGetWorkResults(context.Background(), tt.args)
+			if err != nil {
+				t.Fatalf("handleGetWorkResults returned error: %v", err)
+			}
+			summary, data := decodeResults(t, content)
+			if data != tt.want || summary["offset"] != tt.offset || summary["truncated"] != tt.truncated {
+				t.Errorf("Expected %q at %v (truncated %t), got %q with %v", tt.want, tt.offset, tt.truncated, data, summary)
+			}
+		})
+	}
+
+	for name, args := range map[string]getWorkResultsArgs{
+		"negative offset":  {Offset: -1},
+		"negative limit":   {Limit: -1},
+		"tail with offset": {Tail: 2, Offset: 3},
+	} {
+		args.WorkID = "unitA"
+		if _, err := h.handleGetWorkResults(context.Background(), args); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestTailStdout(t *testing.T) {
+	fake := newFakeControl(t, "controller")
+	h := newTestHandlers(t, fake)
+	client, err := h.pool.Client(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	long := strings.Repeat("x", tailWindow+100) + "\n"
+	tests := []struct {
+		name   string
+		output string
+		lines  int
+		limit  int64
+		want   string
+		offset int64
+	}{
+		{"last lines", numberedLines(5), 2, maxResultBytes, "line 4\nline 5\n", 21},
+		{"more lines than output", numberedLines(3), 10, maxResultBytes, numberedLines(3), 0},
+		{"no final newline", "a\nb\nc", 2, maxResultBytes, "b\nc", 2},
+		{"empty", "", 5, maxResultBytes, "", 0},
+		// Lines beyond the first window are found by growing it
+		{"window grows", "first\n" + long, 2, maxResultBytes, "first\n" + long, 0},
+		// The limit cuts the only line short
+		{"limit", long, 1, 10, "xxxxxxxxx\n", int64(len(long)) - 10},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unitID := fmt.Sprintf("unit%d", i)
+			fake.addUnit(unitID, receptor.WorkStatus{State: receptor.WorkStateSucceeded}, tt.output)
+
+			offset, data, err := tailStdout(context.Background(), client, unitID, int64(len(tt.output)), tt.lines, tt.limit)
+			if err != nil {
+				t.Fatalf("tailStdout returned error: %v", err)
+			}
+			if string(data) != tt.want || offset != tt.offset {
+				t.Errorf("Expected %q at %d, got %q at %d", tt.want, tt.offset, data, offset)
+			}
+		})
+	}
+}
+
+func TestWorkStdoutChunkResource(t *testing.T) {
+	output := strings.Repeat("a", stdoutChunkSize) + strings.Repeat("b", stdoutChunkSize) + "tail"
+	fake := newFakeControl(t, "controller")
+	fake.addUnit("unitA", receptor.WorkStatus{State: receptor.WorkStateSucceeded}, output)
+	fake.addUnit("empty", receptor.WorkStatus{State: receptor.WorkStateSucceeded}, "")
+	h := newTestHandlers(t, fake)
+
+	tests := []struct {
+		unitID string
+		chunk  string
+		want   string
+	}{
+		{"unitA", "0", strings.Repeat("a", stdoutChunkSize)},
+		{"unitA", "1", strings.Repeat("b", stdoutChunkSize)},
+		{"unitA", "2", "tail"},
+		// Chunk 0 of empty output exists, and is empty
+		{"empty", "0", ""},
+	}
+	for _, tt := range tests {
+		uri := workStdoutURI(tt.unitID) + "/" + tt.chunk
+		result, err := h.handleWorkStdoutChunkResource(context.Background(), uri, map[string]string{"unit_id": tt.unitID, "chunk": tt.chunk})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", uri, err)
+			continue
+		}
+		if got := result.(mcp.ResourcesReadResponse).Contents[0].Text; got != tt.want {
+			t.Errorf("%s: expected %d bytes, got %d", uri, len(tt.want), len(got))
+		}
+	}
+
+	for _, chunk := range []string{"3", "-1", "x"} {
+		if _, err := h.handleWorkStdoutChunkResource(context.Background(), "", map[string]string{"unit_id": "unitA", "chunk": chunk}); err == nil {
+			t.Errorf("Expected an error for chunk %s", chunk)
+		}
+	}
+}
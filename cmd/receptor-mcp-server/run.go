@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ansible/receptor-mcp/pkg/mcp"
+)
+
+// runWorkToolName is the submit-and-wait tool. Work type tools are named
+// run_<worktype>, so a work type called "work" gets no tool of its own.
+const runWorkToolName = "run_work"
+
+// run_work returns at most runOutputLines of the end of the output, and no
+// more than runOutputBytes
+const (
+	runOutputLines = 100
+	runOutputBytes = 16 << 10
+)
+
+type runWorkArgs struct {
+	NodeID   string                 `json:"node_id" description:"Target node ID for work execution" required:"true"`
+	WorkType string                 `json:"work_type" description:"Type of work to execute (e.g., ai-script, compute-task)" required:"true"`
+	Payload  string                 `json:"payload" description:"Work payload data, sent to the work unit's stdin"`
+	Params   map[string]interface{} `json:"params" description:"Additional submit parameters as string values (e.g., params for work-command runtime arguments)"`
+	Timeout  int                    `json:"timeout" description:"Seconds to wait for the work to finish (default tools.default_work_timeout)"`
+}
+
+// registerRunTool registers run_work, which submits work and waits for it
+// so assistants need not poll
+func (h *receptorHandlers) registerRunTool(server *mcp.Server) {
+	mcp.RegisterTypedTool(server, mcp.Tool{
+		Name:        runWorkToolName,
+		Description: "Submit work, wait for it to finish and return its final status and the end of its output. If it is still running at the timeout, returns its work ID and current status instead.",
+		Annotations: submitAnnotations("Run work"),
+	}, h.handleRunWork)
+}
+
+func (h *receptorHandlers) handleRunWork(ctx context.Context, args runWorkArgs) (map[string]interface{}, error) {
+	if args.Timeout < 0 {
+		return nil, fmt.Errorf("timeout must not be negative")
+	}
+	timeout := h.workTimeout
+	if args.Timeout > 0 {
+		timeout = time.Duration(args.Timeout) * time.Second
+	}
+
+	client, unitID, err := h.submit(ctx, submitArgs{
+		NodeID:   args.NodeID,
+		WorkType: args.WorkType,
+		Payload:  args.Payload,
+		Params:   args.Params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	status, err := waitForWork(waitCtx, client, unitID)
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		// Still running: report where it got to so the caller can resume
+		status, err := client.WorkStatus(ctx, unitID)
+		if err != nil {
+			return nil, err
+		}
+		h.recordStatus(unitID, status)
+		result := workStatusMap(unitID, status)
+		result["node_id"] = args.NodeID
+		result["timed_out"] = true
+		result["message"] = fmt.Sprintf("Work %s is still %s after %s; use get_work_status, or get_work_results with follow, to resume",
+			unitID, status.State, timeout)
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	h.recordStatus(unitID, status)
+
+	offset, data, err := tailStdout(ctx, client, unitID, status.StdoutSize, runOutputLines, runOutputBytes)
+	if err != nil {
+		return nil, err
+	}
+	if offset == 0 {
+		h.recordFetched(ctx, unitID, status, int64(len(data)))
+	}
+
+	result := workStatusMap(unitID, status)
+	result["node_id"] = args.NodeID
+	result["timed_out"] = false
+	result["stdout"] = string(data)
+	result["stdout_offset"] = offset
+	result["stdout_truncated"] = offset > 0
+	if offset > 0 {
+		result["stdout_uri"] = workStdoutURI(unitID)
+		result["message"] = fmt.Sprintf("Showing the last %d of %d bytes of output; read earlier output with get_work_results or the %s resources",
+			len(data), status.StdoutSize, workStdoutURI(unitID)+"/{chunk}")
+	}
+	return result, nil
+}
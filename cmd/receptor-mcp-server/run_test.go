@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunWorkTimesOut(t *testing.T) {
+	tests := map[string]int{
+		"while polling": 0,
+		// The first status poll after submitting hangs before the greeting
+		"while connecting": 1,
+	}
+	for name, stall := range tests {
+		t.Run(name, func(t *testing.T) {
+			fake := newFakeControl(t, "controller")
+			fake.stallAfterSubmit = stall
+			h := newTestHandlers(t, fake)
+			h.workTimeout = 200 * time.Millisecond
+
+			result, err := h.handleRunWork(context.Background(), runWorkArgs{NodeID: "controller", WorkType: "echo"})
+			if err != nil {
+				t.Fatalf("Expected a timed out result, got error: %v", err)
+			}
+			if result["timed_out"] != true || result["status"] != "Pending" || result["work_id"] == "" {
+				t.Errorf("Expected pending work to time out, got %v", result)
+			}
+			if fake.released(result["work_id"].(string)) {
+				t.Error("Expected work that timed out to be left running")
+			}
+		})
+	}
+}
+
+func TestRunWorkReturnsTail(t *testing.T) {
+	var output strings.Builder
+	for i := 1; i <= runOutputLines+50; i++ {
+		fmt.Fprintf(&output, "line %d\n", i)
+	}
+	fake := newFakeControl(t, "controller")
+	fake.finishSubmitted = true
+	fake.submitOutput = output.String()
+	h := newTestHandlers(t, fake)
+
+	result, err := h.handleRunWork(context.Background(), runWorkArgs{NodeID: "controller", WorkType: "echo", Payload: "hello"})
+	if err != nil {
+		t.Fatalf("handleRunWork returned error: %v", err)
+	}
+	stdout := result["stdout"].(string)
+	if result["timed_out"] != false || result["stdout_truncated"] != true {
+		t.Errorf("Expected finished work with truncated output, got %v", result)
+	}
+	if !strings.HasPrefix(stdout, "line 51\n") || strings.Count(stdout, "\n") != runOutputLines {
+		t.Errorf("Expected the last %d lines, got %q", runOutputLines, stdout)
+	}
+	if record, _ := h.store.Get(result["work_id"].(string)); record.FetchedAt != nil {
+		t.Error("Expected a partial read not to mark the output fetched")
+	}
+}
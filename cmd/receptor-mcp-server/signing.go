@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ansible/receptor-mcp/pkg/receptor"
+	"github.com/spf13/viper"
+)
+
+// newWorkSigner loads the receptor.work_signing private key, returning nil
+// when none is configured
+func newWorkSigner() (*receptor.WorkSigner, error) {
+	keyFile := viper.GetString("receptor.work_signing.private_key")
+	if keyFile == "" {
+		return nil, nil
+	}
+	expiration, err := tokenExpiration()
+	if err != nil {
+		return nil, err
+	}
+	return receptor.NewWorkSigner(keyFile, expiration)
+}
+
+// tokenExpiration returns how long work-signing tokens stay valid, set in
+// seconds like the other timeouts
+func tokenExpiration() (time.Duration, error) {
+	seconds := viper.GetInt("receptor.work_signing.token_expiration")
+	if seconds <= 0 {
+		return 0, fmt.Errorf("receptor.work_signing.token_expiration must be a positive number of seconds, got %q",
+			viper.GetString("receptor.work_signing.token_expiration"))
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// signedWorkTypes returns the work types whose work is signed: those whose
+// work-command definition sets verifysignature, and those listed in
+// receptor.work_signing.worktypes
+func signedWorkTypes(configs map[string]workTypeConfig) map[string]bool {
+	signed := map[string]bool{}
+	for workType, config := range configs {
+		if config.VerifySignature {
+			signed[workType] = true
+		}
+	}
+	for _, workType := range viper.GetStringSlice("receptor.work_signing.worktypes") {
+		signed[workType] = true
+	}
+	return signed
+}
+
+// requiresSignature reports whether work of workType must be signed: it is
+// configured as signed, or a node advertises it as secure
+func (h *receptorHandlers) requiresSignature(workType string) bool {
+	if h.signedWorkTypes[workType] {
+		return true
+	}
+	for _, status := range h.healthyStatuses() {
+		for _, ad := range status.Advertisements {
+			for _, wc := range ad.WorkCommands {
+				if wc.WorkType == workType && wc.Secure {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// signRequest marks work that must be signed so the control service signs
+// it when forwarding it, and attaches a token from the server's own key.
+// Work that must be signed is refused when no key is configured, rather
+// than submitted for the worker to reject.
+func (h *receptorHandlers) signRequest(req *receptor.WorkRequest) error {
+	if !h.requiresSignature(req.WorkType) {
+		return nil
+	}
+	if h.signer == nil {
+		return fmt.Errorf("work type %s requires signed work: set receptor.work_signing.private_key", req.WorkType)
+	}
+	req.SignWork = true
+	token, err := h.signer.Sign(req.Node)
+	if err != nil {
+		return err
+	}
+	req.Signature = token
+	return nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ansible/receptor-mcp/pkg/receptor"
+	"github.com/spf13/viper"
+)
+
+// writeTestKey writes a throwaway RSA key and returns its path
+func writeTestKey(t *testing.T) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "work-signing.key")
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// newTestSigner returns a WorkSigner with a throwaway RSA key
+func newTestSigner(t *testing.T) *receptor.WorkSigner {
+	t.Helper()
+	signer, err := receptor.NewWorkSigner(writeTestKey(t), time.Minute)
+	if err != nil {
+		t.Fatalf("NewWorkSigner returned error: %v", err)
+	}
+	return signer
+}
+
+func TestSignedWorkTypes(t *testing.T) {
+	setConfig(t, "receptor.work_signing.worktypes", []string{"deploy"})
+
+	configs := map[string]workTypeConfig{
+		"echo":           {WorkType: "echo"},
+		"model-training": {WorkType: "model-training", VerifySignature: true},
+	}
+	signed := signedWorkTypes(configs)
+	for workType, want := range map[string]bool{"echo": false, "model-training": true, "deploy": true, "sleep": false} {
+		if signed[workType] != want {
+			t.Errorf("%s: expected signed=%t", workType, want)
+		}
+	}
+}
+
+func TestNewWorkSignerTokenExpiration(t *testing.T) {
+	// The value shipped in receptor-mcp.yaml
+	config := viper.New()
+	config.SetConfigFile("../../receptor-mcp.yaml")
+	if err := config.ReadInConfig(); err != nil {
+		t.Fatalf("Failed to read receptor-mcp.yaml: %v", err)
+	}
+	setConfig(t, "receptor.work_signing.token_expiration", config.Get("receptor.work_signing.token_expiration"))
+	setConfig(t, "receptor.work_signing.private_key", writeTestKey(t))
+
+	signer, err := newWorkSigner()
+	if err != nil {
+		t.Fatalf("newWorkSigner returned error: %v", err)
+	}
+	token, err := signer.Sign("worker-01")
+	if err != nil {
+		t.Fatalf("Sign returned error: %v", err)
+	}
+	payload, _ := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[1])
+	var claims struct {
+		ExpiresAt int64 `json:"exp"`
+	}
+	json.Unmarshal(payload, &claims)
+	if valid := time.Until(time.Unix(claims.ExpiresAt, 0)); valid < 4*time.Minute || valid > 5*time.Minute {
+		t.Errorf("Expected a token valid for 5 minutes, got %v", valid)
+	}
+
+	for _, value := range []interface{}{"5m", 0, -30} {
+		setConfig(t, "receptor.work_signing.token_expiration", value)
+		if _, err := newWorkSigner(); err == nil {
+			t.Errorf("Expected an error for token_expiration %v", value)
+		}
+	}
+}
+
+func TestRequiresSignature(t *testing.T) {
+	fake := newFakeControl(t, "controller")
+	fake.advertise("controller", false, "echo")
+	fake.advertise("worker-01", true, "model-inference")
+	h := newTestHandlers(t, fake)
+	h.signedWorkTypes = map[string]bool{"model-training": true}
+	h.pool.Check(context.Background())
+
+	tests := map[string]bool{
+		"echo":            false,
+		"model-inference": true, // advertised as secure
+		"model-training":  true, // configured
+		"unknown":         false,
+	}
+	for workType, want := range tests {
+		if got := h.requiresSignature(workType); got != want {
+			t.Errorf("requiresSignature(%q) = %t, want %t", workType, got, want)
+		}
+	}
+}
+
+func TestSignRequest(t *testing.T) {
+	fake := newFakeControl(t, "controller")
+	h := newTestHandlers(t, fake)
+	h.signedWorkTypes = map[string]bool{"model-training": true}
+
+	// Work that needs no signing goes out as is, with or without a key
+	req := receptor.WorkRequest{Node: "worker-01", WorkType: "echo"}
+	if err := h.signRequest(&req); err != nil || req.SignWork || req.Signature != "" {
+		t.Errorf("Expected unsigned echo work, got %+v, %v", req, err)
+	}
+
+	// Without a key, work that must be signed is refused
+	req = receptor.WorkRequest{Node: "worker-01", WorkType: "model-training"}
+	if err := h.signRequest(&req); err == nil || !strings.Contains(err.Error(), "private_key") {
+		t.Errorf("Expected an error naming the missing key, got %v", err)
+	}
+	if _, err := h.handleSubmitWork(context.Background(), submitArgs{NodeID: "controller", WorkType: "model-training"}); err == nil {
+		t.Error("Expected submit_work to refuse unsigned work")
+	}
+	if cmd := fake.lastCommand(); cmd["subcommand"] == "submit" {
+		t.Errorf("Expected nothing to be submitted, got %v", cmd)
+	}
+
+	h.signer = newTestSigner(t)
+	req = receptor.WorkRequest{Node: "worker-01", WorkType: "model-training"}
+	if err := h.signRequest(&req); err != nil {
+		t.Fatalf("signRequest returned error: %v", err)
+	}
+	if !req.SignWork || strings.Count(req.Signature, ".") != 2 {
+		t.Errorf("Expected signwork and a JWT, got %+v", req)
+	}
+
+	if _, err := h.handleSubmitWork(context.Background(), submitArgs{NodeID: "controller", WorkType: "model-training"}); err != nil {
+		t.Fatalf("handleSubmitWork returned error: %v", err)
+	}
+	if cmd := fake.lastCommand(); cmd["signwork"] != "true" || cmd["signature"] == nil {
+		t.Errorf("Expected signed work to be submitted, got %v", cmd)
+	}
+}
@@ -0,0 +1,491 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ansible/receptor-mcp/pkg/mcp"
+	"github.com/ansible/receptor-mcp/pkg/receptor"
+	"github.com/ansible/receptor-mcp/pkg/workstore"
+)
+
+// workPollInterval is how often a waiting tool polls work unit status
+const workPollInterval = time.Second
+
+// receptorHandlers implements the MCP tools, resources and prompts on top
+// of a pool of Receptor control service connections. Work submitted
+// through them is tracked in store.
+type receptorHandlers struct {
+	pool   *receptor.Pool
+	store  *workstore.Store
+	logger *mcp.Logger
+
+	// workTimeout is how long run_work waits by default
+	workTimeout time.Duration
+
+	// Work of signedWorkTypes is signed, with signer's key if set
+	signer          *receptor.WorkSigner
+	signedWorkTypes map[string]bool
+}
+
+// primaryStatus returns the status of the first healthy entry point
+func (h *receptorHandlers) primaryStatus(ctx context.Context) (*receptor.Status, error) {
+	client, err := h.pool.Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.Status(ctx)
+}
+
+// healthyStatuses returns the last status of every healthy entry point
+func (h *receptorHandlers) healthyStatuses() []*receptor.Status {
+	var statuses []*receptor.Status
+	for _, state := range h.pool.States() {
+		if state.Healthy {
+			statuses = append(statuses, state.Status)
+		}
+	}
+	return statuses
+}
+
+// registerReceptorTools registers the 7 Receptor tools defined in the design
+func (h *receptorHandlers) registerReceptorTools(server *mcp.Server) {
+	mcp.RegisterTypedTool(server, mcp.Tool{
+		Name:        "submit_work",
+		Description: "Submit work to a Receptor node for execution",
+		Annotations: submitAnnotations("Submit work"),
+	}, h.handleSubmitWork)
+
+	mcp.RegisterTypedTool(server, mcp.Tool{
+		Name:        "get_work_status",
+		Description: "Get the status of submitted work",
+		Annotations: readOnlyAnnotations("Get work status"),
+	}, h.handleGetWorkStatus)
+
+	mcp.RegisterTypedTool(server, mcp.Tool{
+		Name:        "list_nodes",
+		Description: "List all nodes in the Receptor mesh",
+		Annotations: readOnlyAnnotations("List nodes"),
+	}, h.handleListNodes)
+
+	mcp.RegisterTypedTool(server, mcp.Tool{
+		Name:        "get_node_info",
+		Description: "Get detailed information about a specific node",
+		Annotations: readOnlyAnnotations("Get node info"),
+	}, h.handleGetNodeInfo)
+
+	mcp.RegisterTypedTool(server, mcp.Tool{
+		Name:        "get_mesh_status",
+		Description: "Get overall mesh network status and topology",
+		Annotations: readOnlyAnnotations("Get mesh status"),
+	}, h.handleGetMeshStatus)
+
+	mcp.RegisterTypedTool(server, mcp.Tool{
+		Name:        "cancel_work",
+		Description: "Cancel running or pending work",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Cancel work",
+			DestructiveHint: hint(true),
+			IdempotentHint:  hint(true),
+			OpenWorldHint:   hint(false),
+		},
+	}, h.handleCancelWork)
+
+	mcp.RegisterTypedTool(server, mcp.Tool{
+		Name:        "get_work_results",
+		Description: "Retrieve work output: a byte range, the last lines, or everything once the work finishes",
+		Annotations: readOnlyAnnotations("Get work results"),
+	}, h.handleGetWorkResults)
+}
+
+// readOnlyAnnotations describes a tool that only reads mesh state
+func readOnlyAnnotations(title string) *mcp.ToolAnnotations {
+	return &mcp.ToolAnnotations{Title: title, ReadOnlyHint: hint(true), OpenWorldHint: hint(false)}
+}
+
+// submitAnnotations describes a tool that starts new work units, which
+// changes mesh state without destroying anything
+func submitAnnotations(title string) *mcp.ToolAnnotations {
+	return &mcp.ToolAnnotations{Title: title, DestructiveHint: hint(false), OpenWorldHint: hint(false)}
+}
+
+func hint(v bool) *bool {
+	return &v
+}
+
+// Tool arguments
+type submitArgs struct {
+	NodeID   string                 `json:"node_id" description:"Target node ID for work execution" required:"true"`
+	WorkType string                 `json:"work_type" description:"Type of work to execute (e.g., ai-script, compute-task)" required:"true"`
+	Payload  string                 `json:"payload" description:"Work payload data, sent to the work unit's stdin" required:"true"`
+	Params   map[string]interface{} `json:"params" description:"Additional submit parameters as string values (e.g., params for work-command runtime arguments)"`
+	Wait     bool                   `json:"wait" description:"Wait for the work to finish, reporting progress, and return its final status"`
+}
+
+type workIDArgs struct {
+	WorkID string `json:"work_id" description:"Work ID returned from submit_work" required:"true"`
+}
+
+type listNodesArgs struct {
+	Filter string `json:"filter" description:"Optional substring filter on node IDs"`
+}
+
+type nodeIDArgs struct {
+	NodeID string `json:"node_id" description:"Node ID to get information for" required:"true"`
+}
+
+type noArgs struct{}
+
+// parseArgs decodes tool arguments into args
+func parseArgs(params json.RawMessage, args interface{}) error {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(params, args); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+// workStatusMap renders a work unit status for tool output
+func workStatusMap(unitID string, status *receptor.WorkStatus) map[string]interface{} {
+	return map[string]interface{}{
+		"work_id":     unitID,
+		"status":      status.State.String(),
+		"detail":      status.Detail,
+		"work_type":   status.WorkType,
+		"stdout_size": status.StdoutSize,
+	}
+}
+
+// Tool handlers
+func (h *receptorHandlers) handleSubmitWork(ctx context.Context, args submitArgs) (map[string]interface{}, error) {
+	return h.submitWork(ctx, args)
+}
+
+// submitWork submits work to a node, waiting for it to finish if asked to
+func (h *receptorHandlers) submitWork(ctx context.Context, args submitArgs) (map[string]interface{}, error) {
+	client, unitID, err := h.submit(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if args.Wait {
+		status, err := waitForWork(ctx, client, unitID)
+		if err != nil {
+			return nil, err
+		}
+		h.recordStatus(unitID, status)
+		result := workStatusMap(unitID, status)
+		result["node_id"] = args.NodeID
+		return result, nil
+	}
+
+	return map[string]interface{}{
+		"work_id": unitID,
+		"node_id": args.NodeID,
+		"status":  "submitted",
+	}, nil
+}
+
+// submit submits work through an entry point that can reach the target
+// node and records it in the work store, returning the entry point's
+// client and the new unit's ID
+func (h *receptorHandlers) submit(ctx context.Context, args submitArgs) (*receptor.Client, string, error) {
+	if args.NodeID == "" || args.WorkType == "" {
+		return nil, "", fmt.Errorf("node_id and work_type are required")
+	}
+
+	submitParams := make(map[string]string, len(args.Params))
+	for k, v := range args.Params {
+		submitParams[k] = fmt.Sprint(v)
+	}
+
+	client, err := h.pool.ClientFor(ctx, args.NodeID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	req := receptor.WorkRequest{
+		Node:     args.NodeID,
+		WorkType: args.WorkType,
+		Payload:  []byte(args.Payload),
+		Params:   submitParams,
+	}
+	if err := h.signRequest(&req); err != nil {
+		return nil, "", err
+	}
+	unitID, err := client.SubmitWork(ctx, req)
+	if err != nil {
+		return nil, "", err
+	}
+	h.recordSubmission(ctx, unitID, req)
+	return client, unitID, nil
+}
+
+// waitForWork polls a work unit until it reaches a final state. Each state
+// transition and each growth of its stdout is reported as MCP progress.
+func waitForWork(ctx context.Context, client *receptor.Client, unitID string) (*receptor.WorkStatus, error) {
+	ticker := time.NewTicker(workPollInterval)
+	defer ticker.Stop()
+
+	var updates float64
+	lastState := receptor.WorkState(-1)
+	lastSize := int64(-1)
+	for {
+		status, err := client.WorkStatus(ctx, unitID)
+		if err != nil {
+			return nil, err
+		}
+
+		if status.State != lastState || status.StdoutSize != lastSize {
+			lastState, lastSize = status.State, status.StdoutSize
+			updates++
+			message := fmt.Sprintf("Work unit %s is %s (%d bytes of stdout)", unitID, status.State, status.StdoutSize)
+			total := 0.0
+			if status.State.Final() {
+				total = updates
+			}
+			mcp.ReportProgress(ctx, updates, total, message)
+		}
+		if status.State.Final() {
+			return status, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *receptorHandlers) handleGetWorkStatus(ctx context.Context, args workIDArgs) (map[string]interface{}, error) {
+	unitID := args.WorkID
+
+	_, status, err := h.pool.FindWork(ctx, unitID)
+	if err != nil {
+		return nil, err
+	}
+	h.recordStatus(unitID, status)
+
+	return workStatusMap(unitID, status), nil
+}
+
+func (h *receptorHandlers) handleListNodes(ctx context.Context, args listNodesArgs) (map[string]interface{}, error) {
+	// Refresh every entry point so the view of the mesh is current
+	h.pool.Check(ctx)
+
+	entryPoints := []map[string]interface{}{}
+	seenBy := map[string][]string{}
+	var statuses []*receptor.Status
+	for _, state := range h.pool.States() {
+		entry := map[string]interface{}{
+			"name":    state.Name,
+			"healthy": state.Healthy,
+		}
+		if state.LastError != nil {
+			entry["error"] = state.LastError.Error()
+		}
+		if state.Healthy {
+			sees := state.Status.Nodes()
+			sort.Strings(sees)
+			entry["controller"] = state.Status.NodeID
+			entry["sees"] = sees
+			for _, id := range sees {
+				seenBy[id] = append(seenBy[id], state.Name)
+			}
+			statuses = append(statuses, state.Status)
+		}
+		entryPoints = append(entryPoints, entry)
+	}
+	if len(statuses) == 0 {
+		_, err := h.pool.Client(ctx)
+		return nil, err
+	}
+
+	nodeIDs := make([]string, 0, len(seenBy))
+	for id := range seenBy {
+		nodeIDs = append(nodeIDs, id)
+	}
+	sort.Strings(nodeIDs)
+
+	nodes := []map[string]interface{}{}
+	for _, id := range nodeIDs {
+		if args.Filter != "" && !strings.Contains(id, args.Filter) {
+			continue
+		}
+		workTypes := []string{}
+		for _, status := range statuses {
+			workTypes = mergeSorted(workTypes, nodeWorkTypes(status, id))
+		}
+		nodes = append(nodes, map[string]interface{}{
+			"id":        id,
+			"seen_by":   seenBy[id],
+			"worktypes": workTypes,
+		})
+	}
+
+	return map[string]interface{}{
+		"entry_points": entryPoints,
+		"nodes":        nodes,
+	}, nil
+}
+
+// mergeSorted returns the sorted union of two sorted string slices
+func mergeSorted(a, b []string) []string {
+	seen := map[string]bool{}
+	merged := []string{}
+	for _, s := range append(append([]string{}, a...), b...) {
+		if !seen[s] {
+			seen[s] = true
+			merged = append(merged, s)
+		}
+	}
+	sort.Strings(merged)
+	return merged
+}
+
+// nodeWorkTypes returns the sorted work types advertised by a node
+func nodeWorkTypes(status *receptor.Status, nodeID string) []string {
+	seen := map[string]bool{}
+	workTypes := []string{}
+	for _, ad := range status.Advertisements {
+		if ad.NodeID != nodeID {
+			continue
+		}
+		for _, wc := range ad.WorkCommands {
+			if !seen[wc.WorkType] {
+				seen[wc.WorkType] = true
+				workTypes = append(workTypes, wc.WorkType)
+			}
+		}
+	}
+	sort.Strings(workTypes)
+	return workTypes
+}
+
+// nodeConnections returns a node's known peers and their costs
+func nodeConnections(status *receptor.Status, nodeID string) map[string]float64 {
+	connections := map[string]float64{}
+	for peer, cost := range status.KnownConnectionCosts[nodeID] {
+		connections[peer] = cost
+	}
+	if nodeID == status.NodeID {
+		for _, conn := range status.Connections {
+			connections[conn.NodeID] = conn.Cost
+		}
+	}
+	return connections
+}
+
+func (h *receptorHandlers) handleGetNodeInfo(ctx context.Context, args nodeIDArgs) (map[string]interface{}, error) {
+	return h.nodeInfo(ctx, args.NodeID)
+}
+
+// nodeInfo describes a node as seen from its closest entry point
+func (h *receptorHandlers) nodeInfo(ctx context.Context, nodeID string) (map[string]interface{}, error) {
+	client, err := h.pool.ClientFor(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	status, err := client.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	known := false
+	for _, id := range status.Nodes() {
+		if id == nodeID {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return nil, fmt.Errorf("node %s is not known to %s", nodeID, status.NodeID)
+	}
+
+	info := map[string]interface{}{
+		"node_id":     nodeID,
+		"worktypes":   nodeWorkTypes(status, nodeID),
+		"connections": nodeConnections(status, nodeID),
+	}
+	if nodeID == status.NodeID {
+		info["local"] = true
+		info["version"] = status.Version
+		info["cpu_count"] = status.SystemCPUCount
+		info["memory_mib"] = status.SystemMemoryMiB
+	} else {
+		info["route_via"] = status.RoutingTable[nodeID]
+	}
+
+	return info, nil
+}
+
+func (h *receptorHandlers) handleGetMeshStatus(ctx context.Context, args noArgs) (map[string]interface{}, error) {
+	status, err := h.primaryStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	healthyEntryPoints := 0
+	states := h.pool.States()
+	for _, state := range states {
+		if state.Healthy {
+			healthyEntryPoints++
+		}
+	}
+
+	nodes := status.Nodes()
+	unreachable := []string{}
+	for _, id := range nodes {
+		if id != status.NodeID && status.RoutingTable[id] == "" {
+			unreachable = append(unreachable, id)
+		}
+	}
+	sort.Strings(unreachable)
+
+	links := 0
+	for _, peers := range status.KnownConnectionCosts {
+		links += len(peers)
+	}
+
+	health := "healthy"
+	if len(unreachable) > 0 || healthyEntryPoints < len(states) {
+		health = "degraded"
+	}
+
+	return map[string]interface{}{
+		"controller":           status.NodeID,
+		"version":              status.Version,
+		"nodes":                len(nodes),
+		"connections":          links / 2,
+		"direct_peers":         len(status.Connections),
+		"unreachable_nodes":    unreachable,
+		"entry_points":         len(states),
+		"healthy_entry_points": healthyEntryPoints,
+		"health":               health,
+	}, nil
+}
+
+func (h *receptorHandlers) handleCancelWork(ctx context.Context, args workIDArgs) (map[string]interface{}, error) {
+	unitID := args.WorkID
+
+	client, _, err := h.pool.FindWork(ctx, unitID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := client.CancelWork(ctx, unitID); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"work_id": unitID,
+		"status":  "cancelled",
+	}, nil
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/ansible/receptor-mcp/pkg/receptor"
+)
+
+// newTestMesh returns handlers for two entry points: controller-a, which
+// reaches worker-01 and, through it, worker-02, and controller-b, which
+// reaches worker-02 and, through it, worker-03
+func newTestMesh(t *testing.T) (*receptorHandlers, *fakeControl, *fakeControl) {
+	t.Helper()
+	a := newFakeControl(t, "controller-a")
+	a.advertise("controller-a", false, "sleep")
+	a.advertise("worker-01", false, "echo")
+	a.status.Version = "1.5.3"
+	a.status.SystemCPUCount = 4
+	a.status.SystemMemoryMiB = 8192
+	a.status.Connections = []receptor.Connection{{NodeID: "worker-01", Cost: 1}}
+	a.status.RoutingTable = map[string]string{"worker-01": "worker-01", "worker-02": "worker-01"}
+	a.status.KnownConnectionCosts = map[string]map[string]float64{
+		"controller-a": {"worker-01": 1},
+		"worker-01":    {"controller-a": 1, "worker-02": 1},
+		"worker-02":    {"worker-01": 1},
+	}
+
+	b := newFakeControl(t, "controller-b")
+	b.advertise("worker-02", false, "echo", "model-inference")
+	b.status.RoutingTable = map[string]string{"worker-02": "worker-02", "worker-03": "worker-02"}
+
+	return newTestHandlers(t, a, b), a, b
+}
+
+func TestListNodes(t *testing.T) {
+	h, _, _ := newTestMesh(t)
+
+	result, err := h.handleListNodes(context.Background(), listNodesArgs{})
+	if err != nil {
+		t.Fatalf("handleListNodes returned error: %v", err)
+	}
+	entryPoints := []map[string]interface{}{
+		{"name": "localhost", "healthy": true, "controller": "controller-a", "sees": []string{"controller-a", "worker-01", "worker-02"}},
+		{"name": "controller-b", "healthy": true, "controller": "controller-b", "sees": []string{"controller-b", "worker-02", "worker-03"}},
+	}
+	if !reflect.DeepEqual(result["entry_points"], entryPoints) {
+		t.Errorf("Expected entry points %v, got %v", entryPoints, result["entry_points"])
+	}
+	nodes := []map[string]interface{}{
+		{"id": "controller-a", "seen_by": []string{"localhost"}, "worktypes": []string{"sleep"}},
+		{"id": "controller-b", "seen_by": []string{"controller-b"}, "worktypes": []string{}},
+		{"id": "worker-01", "seen_by": []string{"localhost"}, "worktypes": []string{"echo"}},
+		{"id": "worker-02", "seen_by": []string{"localhost", "controller-b"}, "worktypes": []string{"echo", "model-inference"}},
+		{"id": "worker-03", "seen_by": []string{"controller-b"}, "worktypes": []string{}},
+	}
+	if !reflect.DeepEqual(result["nodes"], nodes) {
+		t.Errorf("Expected nodes %v, got %v", nodes, result["nodes"])
+	}
+
+	result, err = h.handleListNodes(context.Background(), listNodesArgs{Filter: "worker-0"})
+	if err != nil {
+		t.Fatalf("handleListNodes returned error: %v", err)
+	}
+	if got := result["nodes"].([]map[string]interface{}); len(got) != 3 || got[0]["id"] != "worker-01" {
+		t.Errorf("Expected the three workers, got %v", got)
+	}
+}
+
+func TestNodeInfo(t *testing.T) {
+	h, _, _ := newTestMesh(t)
+
+	tests := map[string]map[string]interface{}{
+		"controller-a": {
+			"node_id":     "controller-a",
+			"worktypes":   []string{"sleep"},
+			"connections": map[string]float64{"worker-01": 1},
+			"local":       true,
+			"version":     "1.5.3",
+			"cpu_count":   4,
+			"memory_mib":  8192,
+		},
+		"worker-01": {
+			"node_id":     "worker-01",
+			"worktypes":   []string{"echo"},
+			"connections": map[string]float64{"controller-a": 1, "worker-02": 1},
+			"route_via":   "worker-01",
+		},
+		// Only controller-b reaches worker-03
+		"worker-03": {
+			"node_id":     "worker-03",
+			"worktypes":   []string{},
+			"connections": map[string]float64{},
+			"route_via":   "worker-02",
+		},
+	}
+	for nodeID, want := range tests {
+		info, err := h.nodeInfo(context.Background(), nodeID)
+		if err != nil {
+			t.Errorf("%s: nodeInfo returned error: %v", nodeID, err)
+			continue
+		}
+		if !reflect.DeepEqual(info, want) {
+			t.Errorf("%s: expected %v, got %v", nodeID, want, info)
+		}
+	}
+
+	if _, err := h.nodeInfo(context.Background(), "worker-09"); err == nil {
+		t.Error("Expected an error for an unknown node")
+	}
+}
+
+func TestGetMeshStatus(t *testing.T) {
+	h, a, b := newTestMesh(t)
+	h.pool.Check(context.Background())
+
+	result, err := h.handleGetMeshStatus(context.Background(), noArgs{})
+	if err != nil {
+		t.Fatalf("handleGetMeshStatus returned error: %v", err)
+	}
+	want := map[string]interface{}{
+		"controller":           "controller-a",
+		"version":              "1.5.3",
+		"nodes":                3,
+		"connections":          2,
+		"direct_peers":         1,
+		"unreachable_nodes":    []string{},
+		"entry_points":         2,
+		"healthy_entry_points": 2,
+		"health":               "healthy",
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Expected %v, got %v", want, result)
+	}
+
+	// worker-02 loses its route and controller-b goes away
+	a.mu.Lock()
+	delete(a.status.RoutingTable, "worker-02")
+	a.mu.Unlock()
+	b.listener.Close()
+	h.pool.Check(context.Background())
+
+	result, err = h.handleGetMeshStatus(context.Background(), noArgs{})
+	if err != nil {
+		t.Fatalf("handleGetMeshStatus returned error: %v", err)
+	}
+	if result["health"] != "degraded" || result["healthy_entry_points"] != 1 || !reflect.DeepEqual(result["unreachable_nodes"], []string{"worker-02"}) {
+		t.Errorf("Expected a degraded mesh, got %v", result)
+	}
+}
+
+func TestGetWorkStatus(t *testing.T) {
+	fake := newFakeControl(t, "controller")
+	h := newTestHandlers(t, fake)
+	trackUnit(t, h, fake, "unitA", "worker-01", "echo", receptor.WorkStateRunning, 0, false)
+	fake.addUnit("unitA", receptor.WorkStatus{State: receptor.WorkStateSucceeded, Detail: "exit status 0", WorkType: "echo"}, "done\n")
+
+	result, err := h.handleGetWorkStatus(context.Background(), workIDArgs{WorkID: "unitA"})
+	if err != nil {
+		t.Fatalf("handleGetWorkStatus returned error: %v", err)
+	}
+	want := map[string]interface{}{
+		"work_id":     "unitA",
+		"status":      "Succeeded",
+		"detail":      "exit status 0",
+		"work_type":   "echo",
+		"stdout_size": int64(5),
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Expected %v, got %v", want, result)
+	}
+	if record, _ := h.store.Get("unitA"); record.State != "Succeeded" || !record.Finished() {
+		t.Errorf("Expected the new status to be recorded, got %+v", record)
+	}
+
+	if _, err := h.handleGetWorkStatus(context.Background(), workIDArgs{WorkID: "missing"}); err == nil {
+		t.Error("Expected an error for an unknown unit")
+	}
+}
+
+func TestCancelWork(t *testing.T) {
+	fake := newFakeControl(t, "controller")
+	fake.addUnit("unitA", receptor.WorkStatus{State: receptor.WorkStateRunning, WorkType: "echo"}, "")
+	h := newTestHandlers(t, fake)
+
+	result, err := h.handleCancelWork(context.Background(), workIDArgs{WorkID: "unitA"})
+	if err != nil {
+		t.Fatalf("handleCancelWork returned error: %v", err)
+	}
+	if result["status"] != "cancelled" || result["work_id"] != "unitA" {
+		t.Errorf("Unexpected result %v", result)
+	}
+	if cmd := fake.lastCommand(); cmd["subcommand"] != "cancel" || cmd["unitid"] != "unitA" {
+		t.Errorf("Expected unitA to be cancelled, got %v", cmd)
+	}
+
+	if _, err := h.handleCancelWork(context.Background(), workIDArgs{WorkID: "missing"}); err == nil {
+		t.Error("Expected an error for an unknown unit")
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ansible/receptor-mcp/pkg/mcp"
+)
+
+// watchedResource is a resource polled for changes on behalf of subscribers
+type watchedResource struct {
+	uri      string
+	interval time.Duration
+	read     mcp.Handler
+}
+
+type pollContextKey struct{}
+
+// withPoll marks ctx as the server polling resources for changes on
+// behalf of subscribers, rather than a client reading them
+func withPoll(ctx context.Context) context.Context {
+	return context.WithValue(ctx, pollContextKey{}, true)
+}
+
+// isPoll reports whether a resource is being read by the server's own poll
+func isPoll(ctx context.Context) bool {
+	poll, _ := ctx.Value(pollContextKey{}).(bool)
+	return poll
+}
+
+// watchResources polls the subscribable resources at their configured
+// refresh intervals until ctx is done, notifying subscribers whenever a
+// resource's content changes. Resources without subscribers are not read.
+// Subscribed resources generated by templates, such as a work unit's
+// status, are polled at the work queue interval.
+func (h *receptorHandlers) watchResources(ctx context.Context, server *mcp.Server, topology, nodeStatus, workQueue time.Duration) {
+	watched := []watchedResource{
+		{"receptor://mesh/topology", topology, h.handleMeshTopologyResource},
+		{"receptor://nodes/status", nodeStatus, h.handleNodeStatusResource},
+		{"receptor://work/queue", workQueue, h.handleWorkQueueResource},
+		{"receptor://work/history", workQueue, h.handleWorkHistoryResource},
+	}
+	logger := server.Logger("receptor.resources")
+	static := map[string]bool{}
+	for _, resource := range watched {
+		static[resource.uri] = true
+		if resource.interval <= 0 {
+			continue
+		}
+		go resource.watch(ctx, server, logger)
+	}
+	if workQueue > 0 {
+		go watchSubscriptions(ctx, server, logger, workQueue, static)
+	}
+}
+
+// watch polls one resource until ctx is done
+func (w watchedResource) watch(ctx context.Context, server *mcp.Server, logger *mcp.Logger) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	// last is the content subscribers were last told about, or nil when
+	// nobody was subscribed at the previous poll
+	var last []byte
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !server.HasSubscribers(w.uri) {
+			last = nil
+			continue
+		}
+
+		result, err := w.read(withPoll(ctx), nil)
+		if err != nil {
+			logger.Warningf("Polling %s failed: %v", w.uri, err)
+			continue
+		}
+		snapshot, err := json.Marshal(result)
+		if err != nil {
+			logger.Errorf("Encoding %s failed: %v", w.uri, err)
+			continue
+		}
+
+		// The first snapshot after subscribing is the baseline
+		if last != nil && !bytes.Equal(last, snapshot) {
+			logger.Debugf("%s changed, notifying subscribers", w.uri)
+			server.NotifyResourceUpdated(w.uri)
+		}
+		last = snapshot
+	}
+}
+
+// watchSubscriptions polls every subscribed resource not in static every
+// interval until ctx is done, notifying subscribers when one changes
+func watchSubscriptions(ctx context.Context, server *mcp.Server, logger *mcp.Logger, interval time.Duration, static map[string]bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// last holds the content subscribers were last told about; URIs
+	// nobody is subscribed to any more are dropped
+	last := map[string][]byte{}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current := map[string][]byte{}
+		for _, uri := range server.Subscriptions() {
+			if static[uri] {
+				continue
+			}
+			result, err := server.ReadResource(withPoll(ctx), uri)
+			if err != nil {
+				// Such as a work unit that has been released
+				logger.Debugf("Polling %s failed: %v", uri, err)
+				if previous, ok := last[uri]; ok {
+					current[uri] = previous
+				}
+				continue
+			}
+			snapshot, err := json.Marshal(result)
+			if err != nil {
+				logger.Errorf("Encoding %s failed: %v", uri, err)
+				continue
+			}
+
+			if previous, ok := last[uri]; ok && !bytes.Equal(previous, snapshot) {
+				logger.Debugf("%s changed, notifying subscribers", uri)
+				server.NotifyResourceUpdated(uri)
+			}
+			current[uri] = snapshot
+		}
+		last = current
+	}
+}
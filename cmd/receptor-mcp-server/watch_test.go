@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ansible/receptor-mcp/pkg/mcp"
+	"github.com/ansible/receptor-mcp/pkg/receptor"
+)
+
+func TestWatchSubscriptionsNotifiesWorkUnitChanges(t *testing.T) {
+	fake := newFakeControl(t, "controller")
+	fake.addUnit("unitA", receptor.WorkStatus{State: receptor.WorkStateRunning, WorkType: "echo"}, "")
+	h := newTestHandlers(t, fake)
+
+	server := mcp.NewServer("test-server", "1.0.0")
+	h.registerReceptorResources(server)
+	client := startMCPClient(t, server)
+	uri := "receptor://work/unitA/status"
+	client.call("resources/subscribe", mcp.ResourcesSubscribeRequest{URI: uri})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watchSubscriptions(ctx, server, server.Logger("receptor.resources"), 20*time.Millisecond, map[string]bool{})
+	time.Sleep(100 * time.Millisecond)
+	fake.addUnit("unitA", receptor.WorkStatus{State: receptor.WorkStateSucceeded, WorkType: "echo"}, "done\n")
+
+	msg := client.read()
+	var updated mcp.ResourceUpdatedNotification
+	json.Unmarshal(msg.Params, &updated)
+	if msg.Method != "notifications/resources/updated" || updated.URI != uri {
+		t.Errorf("Expected an update notification for %s, got %+v", uri, msg)
+	}
+}
+
+func TestPolledStdoutIsNotFetched(t *testing.T) {
+	fake := newFakeControl(t, "controller")
+	h := newTestHandlers(t, fake)
+	trackUnit(t, h, fake, "unitA", "worker-01", "echo", receptor.WorkStateSucceeded, time.Hour, false)
+
+	server := mcp.NewServer("test-server", "1.0.0")
+	h.registerReceptorResources(server)
+	client := startMCPClient(t, server)
+	uri := workStdoutURI("unitA")
+	client.call("resources/subscribe", mcp.ResourcesSubscribeRequest{URI: uri})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go watchSubscriptions(ctx, server, server.Logger("receptor.resources"), 20*time.Millisecond, map[string]bool{})
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	// The server's polls are not the client reading the output
+	h.reap(context.Background(), 0)
+	if fake.released("unitA") {
+		t.Fatal("Expected output only polled by the server to be kept")
+	}
+
+	client.call("resources/read", mcp.ResourcesReadRequest{URI: uri})
+	h.reap(context.Background(), 0)
+	if !fake.released("unitA") {
+		t.Error("Expected output read by the client to be released")
+	}
+}
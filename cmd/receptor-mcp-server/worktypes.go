@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ansible/receptor-mcp/pkg/mcp"
+	"github.com/ansible/receptor-mcp/pkg/receptor"
+	"gopkg.in/yaml.v3"
+)
+
+// workTypeTools keeps one MCP tool per work type advertised on the mesh,
+// adding tools as work types appear and removing them when no node
+// advertises them any more
+type workTypeTools struct {
+	server   *mcp.Server
+	handlers *receptorHandlers
+	configs  map[string]workTypeConfig
+	logger   *mcp.Logger
+
+	// registered maps tool names to a signature of the advertisement they
+	// were generated from
+	registered map[string]string
+}
+
+// workTypeConfig is the part of a Receptor work-command definition that
+// shapes its tool. Advertisements only carry the work type name and whether
+// it is signed, so these come from the Receptor configuration files.
+type workTypeConfig struct {
+	WorkType           string `yaml:"worktype"`
+	Description        string `yaml:"description"`
+	AllowRuntimeParams bool   `yaml:"allowruntimeparams"`
+	AllowRuntimeStdin  bool   `yaml:"allowruntimestdin"`
+	VerifySignature    bool   `yaml:"verifysignature"`
+}
+
+// loadWorkTypeConfigs reads the work-command definitions from Receptor
+// configuration files matching the given glob patterns, keyed by work type
+func loadWorkTypeConfigs(patterns []string) (map[string]workTypeConfig, error) {
+	configs := map[string]workTypeConfig{}
+	for _, pattern := range patterns {
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid work type config pattern %q: %w", pattern, err)
+		}
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+
+			// Receptor configurations are lists of single-key entries
+			var entries []map[string]yaml.Node
+			if err := yaml.Unmarshal(data, &entries); err != nil {
+				return nil, fmt.Errorf("parsing %s: %w", path, err)
+			}
+			for _, entry := range entries {
+				node, ok := entry["work-command"]
+				if !ok {
+					continue
+				}
+				var config workTypeConfig
+				if err := node.Decode(&config); err != nil {
+					return nil, fmt.Errorf("parsing work-command in %s: %w", path, err)
+				}
+				if config.WorkType != "" {
+					configs[config.WorkType] = config
+				}
+			}
+		}
+	}
+	return configs, nil
+}
+
+// syncWorkTypeTools keeps the work type tools in step with the mesh until
+// ctx is done, rescanning the entry points' advertisements every interval
+func (h *receptorHandlers) syncWorkTypeTools(ctx context.Context, server *mcp.Server, configs map[string]workTypeConfig, interval time.Duration) {
+	w := &workTypeTools{
+		server:     server,
+		handlers:   h,
+		configs:    configs,
+		logger:     server.Logger("receptor.worktypes"),
+		registered: map[string]string{},
+	}
+
+	h.pool.Check(ctx)
+	w.sync()
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.sync()
+		}
+	}
+}
+
+// sync registers, updates and unregisters work type tools to match the
+// current advertisements. Nothing changes while no entry point is healthy,
+// so a transient outage does not strip the tool list.
+func (w *workTypeTools) sync() {
+	statuses := w.handlers.healthyStatuses()
+	if len(statuses) == 0 {
+		return
+	}
+
+	workTypes := advertisedWorkTypes(statuses)
+	seen := map[string]bool{}
+	for _, workType := range sortedKeys(workTypes) {
+		ad := workTypes[workType]
+		name := workTypeToolName(workType)
+		if name == runWorkToolName {
+			w.logger.Warningf("Work type %s maps to tool %s, which is reserved; use %s with work_type=%s instead", workType, name, runWorkToolName, workType)
+			continue
+		}
+		if seen[name] {
+			w.logger.Warningf("Work type %s maps to tool %s, which is already taken", workType, name)
+			continue
+		}
+		seen[name] = true
+
+		signature := fmt.Sprintf("%s secure=%t", strings.Join(ad.nodes, ","), ad.secure)
+		if w.registered[name] == signature {
+			continue
+		}
+		w.registered[name] = signature
+		config, known := w.configs[workType]
+		if !known {
+			w.logger.Debugf("No work-command configuration for work type %s, offering every argument", workType)
+		}
+		w.logger.Infof("Offering tool %s for work type %s on %s", name, workType, strings.Join(ad.nodes, ", "))
+		w.server.RegisterTool(workTypeTool(name, workType, ad, config, known), w.handlers.workTypeHandler(workType, ad, config, known))
+	}
+
+	for name := range w.registered {
+		if !seen[name] {
+			delete(w.registered, name)
+			w.server.UnregisterTool(name)
+			w.logger.Infof("Removed tool %s, its work type is no longer advertised", name)
+		}
+	}
+}
+
+// workTypeAdvertisement is what the mesh advertises about a work type
+type workTypeAdvertisement struct {
+	// nodes are the sorted IDs of the nodes offering the work type
+	nodes []string
+	// secure is set if any node requires the work to be signed
+	secure bool
+}
+
+// offeredBy reports whether a node advertises the work type
+func (ad *workTypeAdvertisement) offeredBy(nodeID string) bool {
+	i := sort.SearchStrings(ad.nodes, nodeID)
+	return i < len(ad.nodes) && ad.nodes[i] == nodeID
+}
+
+// advertisedWorkTypes collects the work types advertised on the mesh
+func advertisedWorkTypes(statuses []*receptor.Status) map[string]*workTypeAdvertisement {
+	nodes := map[string]map[string]bool{}
+	workTypes := map[string]*workTypeAdvertisement{}
+	for _, status := range statuses {
+		for _, ad := range status.Advertisements {
+			for _, wc := range ad.WorkCommands {
+				if workTypes[wc.WorkType] == nil {
+					workTypes[wc.WorkType] = &workTypeAdvertisement{}
+					nodes[wc.WorkType] = map[string]bool{}
+				}
+				nodes[wc.WorkType][ad.NodeID] = true
+				workTypes[wc.WorkType].secure = workTypes[wc.WorkType].secure || wc.Secure
+			}
+		}
+	}
+
+	for workType, ad := range workTypes {
+		ad.nodes = sortedKeys(nodes[workType])
+	}
+	return workTypes
+}
+
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// invalidToolNameChars matches characters not allowed in tool names
+var invalidToolNameChars = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// workTypeToolName names the tool for a work type, e.g. run_model_inference
+// for model-inference
+func workTypeToolName(workType string) string {
+	return "run_" + strings.ToLower(invalidToolNameChars.ReplaceAllString(workType, "_"))
+}
+
+// workTypeTool describes the tool for a work type. Its schema only offers
+// node_id values that advertise the work type, and payload and params only
+// when the work-command accepts them. Work types without a known
+// configuration accept both, leaving Receptor to reject what it must.
+func workTypeTool(name, workType string, ad *workTypeAdvertisement, config workTypeConfig, known bool) mcp.Tool {
+	description := config.Description
+	if description == "" {
+		description = fmt.Sprintf("Run %s work", workType)
+	}
+	description += fmt.Sprintf(" (work type %s on %s", workType, strings.Join(ad.nodes, ", "))
+	if ad.secure || config.VerifySignature {
+		description += "; signed work"
+	}
+	description += ")"
+
+	properties := map[string]interface{}{
+		"node_id": map[string]interface{}{
+			"type":        "string",
+			"description": "Node to run the work on",
+			"enum":        ad.nodes,
+			"default":     ad.nodes[0],
+		},
+		"wait": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Wait for the work to finish, reporting progress, and return its final status",
+		},
+	}
+	var required []string
+	if !known || config.AllowRuntimeStdin {
+		properties["payload"] = map[string]interface{}{
+			"type":        "string",
+			"description": "Input data, sent to the work unit's stdin",
+		}
+		required = append(required, "payload")
+	}
+	if !known || config.AllowRuntimeParams {
+		properties["params"] = map[string]interface{}{
+			"type":        "string",
+			"description": "Command-line parameters appended to the work command",
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return mcp.Tool{
+		Name:        name,
+		Description: description,
+		InputSchema: schema,
+		Annotations: submitAnnotations("Run " + workType),
+	}
+}
+
+// workTypeHandler submits work of one type, to the first node offering it
+// unless the caller picks one
+func (h *receptorHandlers) workTypeHandler(workType string, ad *workTypeAdvertisement, config workTypeConfig, known bool) mcp.Handler {
+	return func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		var args struct {
+			NodeID  string `json:"node_id"`
+			Payload string `json:"payload"`
+			Params  string `json:"params"`
+			Wait    bool   `json:"wait"`
+		}
+		if err := parseArgs(params, &args); err != nil {
+			return nil, err
+		}
+
+		if args.NodeID == "" {
+			args.NodeID = ad.nodes[0]
+		} else if !ad.offeredBy(args.NodeID) {
+			return nil, fmt.Errorf("node %s does not offer work type %s", args.NodeID, workType)
+		}
+		if known && args.Payload != "" && !config.AllowRuntimeStdin {
+			return nil, fmt.Errorf("work type %s does not accept a payload", workType)
+		}
+		if known && args.Params != "" && !config.AllowRuntimeParams {
+			return nil, fmt.Errorf("work type %s does not accept runtime params", workType)
+		}
+
+		submit := submitArgs{
+			NodeID:   args.NodeID,
+			WorkType: workType,
+			Payload:  args.Payload,
+			Wait:     args.Wait,
+		}
+		if args.Params != "" {
+			submit.Params = map[string]interface{}{"params": args.Params}
+		}
+		return h.submitWork(ctx, submit)
+	}
+}
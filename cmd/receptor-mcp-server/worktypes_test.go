@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ansible/receptor-mcp/pkg/mcp"
+)
+
+func TestLoadWorkTypeConfigs(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "worker.yaml"), []byte(`
+- node:
+    id: worker-01
+- work-command:
+    worktype: echo
+    command: /bin/echo
+    allowruntimeparams: true
+- work-command:
+    worktype: model-training
+    command: /usr/bin/train
+    allowruntimestdin: true
+    verifysignature: true
+    description: "Train models"
+- work-command:
+    command: /bin/missing-worktype
+`), 0o600)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not matched"), 0o600)
+
+	configs, err := loadWorkTypeConfigs([]string{filepath.Join(dir, "*.yaml"), filepath.Join(dir, "none-*.yaml")})
+	if err != nil {
+		t.Fatalf("loadWorkTypeConfigs returned error: %v", err)
+	}
+	want := map[string]workTypeConfig{
+		"echo":           {WorkType: "echo", AllowRuntimeParams: true},
+		"model-training": {WorkType: "model-training", Description: "Train models", AllowRuntimeStdin: true, VerifySignature: true},
+	}
+	if !reflect.DeepEqual(configs, want) {
+		t.Errorf("Expected %+v, got %+v", want, configs)
+	}
+
+	// The shipped work type definitions load
+	configs, err = loadWorkTypeConfigs([]string{"../../configs/work-types/*.yaml"})
+	if err != nil || !configs["model-training"].VerifySignature {
+		t.Errorf("Expected the shipped definitions to load, got %d configs, %v", len(configs), err)
+	}
+}
+
+func TestLoadWorkTypeConfigsErrors(t *testing.T) {
+	invalid := filepath.Join(t.TempDir(), "invalid.yaml")
+	os.WriteFile(invalid, []byte("- work-command: [unterminated"), 0o600)
+
+	tests := map[string]string{
+		"bad pattern": "[",
+		"bad YAML":    invalid,
+	}
+	for name, pattern := range tests {
+		if _, err := loadWorkTypeConfigs([]string{pattern}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestWorkTypeToolName(t *testing.T) {
+	tests := map[string]string{
+		"echo":            "run_echo",
+		"model-inference": "run_model_inference",
+		"Data.Ingest v2":  "run_data_ingest_v2",
+		"a--b":            "run_a_b",
+		"work":            runWorkToolName,
+	}
+	for workType, want := range tests {
+		if got := workTypeToolName(workType); got != want {
+			t.Errorf("workTypeToolName(%q) = %q, want %q", workType, got, want)
+		}
+	}
+}
+
+func TestWorkTypeToolSchema(t *testing.T) {
+	ad := &workTypeAdvertisement{nodes: []string{"worker-01", "worker-02"}}
+	tests := []struct {
+		name       string
+		config     workTypeConfig
+		known      bool
+		secure     bool
+		properties []string
+		required   []string
+		signed     bool
+	}{
+		{"unknown", workTypeConfig{}, false, false, []string{"node_id", "params", "payload", "wait"}, []string{"payload"}, false},
+		{"fixed", workTypeConfig{WorkType: "echo"}, true, false, []string{"node_id", "wait"}, nil, false},
+		{"params", workTypeConfig{WorkType: "echo", AllowRuntimeParams: true}, true, false, []string{"node_id", "params", "wait"}, nil, false},
+		{"stdin", workTypeConfig{WorkType: "echo", AllowRuntimeStdin: true}, true, false, []string{"node_id", "payload", "wait"}, []string{"payload"}, false},
+		{"advertised secure", workTypeConfig{WorkType: "echo"}, true, true, []string{"node_id", "wait"}, nil, true},
+		{"verifysignature", workTypeConfig{WorkType: "echo", VerifySignature: true}, true, false, []string{"node_id", "wait"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ad.secure = tt.secure
+			tool := workTypeTool("run_echo", "echo", ad, tt.config, tt.known)
+
+			properties := tool.InputSchema["properties"].(map[string]interface{})
+			if got := sortedKeys(properties); !reflect.DeepEqual(got, tt.properties) {
+				t.Errorf("Expected properties %v, got %v", tt.properties, got)
+			}
+			required, _ := tool.InputSchema["required"].([]string)
+			if !reflect.DeepEqual(required, tt.required) {
+				t.Errorf("Expected required %v, got %v", tt.required, required)
+			}
+			node := properties["node_id"].(map[string]interface{})
+			if !reflect.DeepEqual(node["enum"], ad.nodes) || node["default"] != "worker-01" {
+				t.Errorf("Expected node_id to offer the advertising nodes, got %v", node)
+			}
+			if signed := strings.Contains(tool.Description, "signed work"); signed != tt.signed {
+				t.Errorf("Expected signed=%t in description %q", tt.signed, tool.Description)
+			}
+		})
+	}
+}
+
+func TestSyncWorkTypeTools(t *testing.T) {
+	fake := newFakeControl(t, "controller")
+	fake.advertise("controller", false, "echo", "work")
+	fake.advertise("worker-01", true, "model-inference", "model.inference")
+	fake.advertise("worker-02", false, "echo")
+	h := newTestHandlers(t, fake)
+
+	server := mcp.NewServer("test-server", "1.0.0")
+	client := startMCPClient(t, server)
+	w := &workTypeTools{
+		server:     server,
+		handlers:   h,
+		configs:    map[string]workTypeConfig{"echo": {WorkType: "echo", AllowRuntimeStdin: true}},
+		logger:     server.Logger("receptor.worktypes"),
+		registered: map[string]string{},
+	}
+	h.pool.Check(context.Background())
+	w.sync()
+
+	tools := client.tools()
+	if got := sortedKeys(tools); !reflect.DeepEqual(got, []string{"run_echo", "run_model_inference"}) {
+		t.Fatalf("Expected a tool per work type, without collisions or reserved names, got %v", got)
+	}
+	node := tools["run_echo"].InputSchema["properties"].(map[string]interface{})["node_id"].(map[string]interface{})
+	if enum := node["enum"].([]interface{}); len(enum) != 2 || enum[0] != "controller" || enum[1] != "worker-02" {
+		t.Errorf("Expected run_echo on controller and worker-02, got %v", enum)
+	}
+
+	// Work types no longer advertised lose their tools
+	fake.mu.Lock()
+	fake.status.Advertisements = fake.status.Advertisements[:1]
+	fake.mu.Unlock()
+	h.pool.Check(context.Background())
+	w.sync()
+
+	if got := sortedKeys(client.tools()); !reflect.DeepEqual(got, []string{"run_echo"}) {
+		t.Errorf("Expected only run_echo after model-inference went away, got %v", got)
+	}
+}
+
+func TestWorkTypeHandlerValidatesArguments(t *testing.T) {
+	fake := newFakeControl(t, "controller")
+	fake.advertise("controller", false, "echo")
+	h := newTestHandlers(t, fake)
+	ad := &workTypeAdvertisement{nodes: []string{"controller"}}
+	handler := h.workTypeHandler("echo", ad, workTypeConfig{WorkType: "echo"}, true)
+
+	tests := map[string]string{
+		"other node": `{"node_id":"worker-09"}`,
+		"payload":    `{"payload":"data"}`,
+		"params":     `{"params":"-n"}`,
+	}
+	for name, args := range tests {
+		if _, err := handler(context.Background(), []byte(args)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	result, err := handler(context.Background(), []byte(`{}`))
+	if err != nil {
+		t.Fatalf("Expected work to be submitted, got error: %v", err)
+	}
+	if cmd := fake.lastCommand(); cmd["worktype"] != "echo" || cmd["node"] != "controller" {
+		t.Errorf("Expected echo work on controller, got %v (result %v)", cmd, result)
+	}
+}
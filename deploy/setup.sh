@@ -222,6 +222,27 @@ generate_work_keys() {
     log_info "Work signing keys generated successfully"
 }
 
+# Generate the bearer token MCP clients use with the production server
+generate_mcp_token() {
+    if [[ "$ENVIRONMENT" != "prod" ]]; then
+        return 0
+    fi
+    
+    local secret_dir="./secrets"
+    mkdir -p "$secret_dir"
+    
+    if [[ -f "$secret_dir/mcp-auth-token" && "$FORCE" != "true" ]]; then
+        log_warn "MCP auth token already exists. Use --force to regenerate."
+        return 0
+    fi
+    
+    log_info "Generating MCP auth token..."
+    openssl rand -hex 32 > "$secret_dir/mcp-auth-token"
+    chmod 600 "$secret_dir/mcp-auth-token"
+    
+    log_info "MCP auth token written to $secret_dir/mcp-auth-token"
+}
+
 # Build configuration generator
 build_config_generator() {
     log_info "Building configuration generator..."
@@ -325,6 +346,7 @@ show_connection_info() {
             ;;
         prod)
             echo "MCP Server Access: Connect to localhost:8888 (with TLS client cert)"
+            echo "MCP HTTP endpoint: http://mcp-server:8889/mcp on the receptor-prod network (bearer token in ./secrets/mcp-auth-token)"
             echo "Controller: docker exec -it receptor-prod-controller receptorctl status"
             echo "Workers: docker-compose -f deploy/docker-compose.prod.yaml ps"
             ;;
@@ -355,6 +377,7 @@ main() {
         prod)
             generate_certificates
             generate_work_keys
+            generate_mcp_token
             ;;
     esac
     
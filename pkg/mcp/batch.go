@@ -0,0 +1,79 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// isBatch reports whether data is a JSON-RPC batch, i.e. a JSON array
+func isBatch(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && data[0] == '['
+}
+
+// handleBatch processes a JSON-RPC batch. Its elements are dispatched
+// concurrently and their responses returned together as one array, in the
+// order of the requests. Notifications contribute no response, so a batch
+// of only notifications returns nil.
+func (s *Server) handleBatch(ctx context.Context, data []byte) []byte {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return s.encodeResponse(errorResponse(nil, ParseError, "Parse error", err.Error()))
+	}
+	if len(elements) == 0 {
+		return s.encodeResponse(errorResponse(nil, InvalidRequest, "Invalid Request", "empty batch"))
+	}
+
+	responses := make([][]byte, len(elements))
+	var wg sync.WaitGroup
+	for i, element := range elements {
+		var probe batchElement
+		if err := json.Unmarshal(element, &probe); err != nil {
+			responses[i] = s.encodeResponse(errorResponse(nil, InvalidRequest, "Invalid Request", err.Error()))
+			continue
+		}
+		if probe.Method == "" {
+			// Responses from the client need no reply; anything else is invalid
+			if probe.Result == nil && probe.Error == nil {
+				responses[i] = s.encodeResponse(errorResponse(probe.ID, InvalidRequest, "Invalid Request", "missing method"))
+			} else {
+				s.handleResponse(ctx, element)
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, element json.RawMessage) {
+			defer wg.Done()
+			responses[i] = s.handleMessage(ctx, element)
+		}(i, element)
+	}
+	wg.Wait()
+
+	batch := make([]json.RawMessage, 0, len(responses))
+	for _, response := range responses {
+		if response != nil {
+			batch = append(batch, response)
+		}
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+
+	encoded, err := json.Marshal(batch)
+	if err != nil {
+		return s.encodeResponse(errorResponse(nil, InternalError, "Internal error", err.Error()))
+	}
+	return encoded
+}
+
+// batchElement is enough of a batch element to tell requests, notifications
+// and responses apart. Nested batches fail to decode into it.
+type batchElement struct {
+	ID     interface{}     `json:"id"`
+	Method string          `json:"method"`
+	Result json.RawMessage `json:"result"`
+	Error  json.RawMessage `json:"error"`
+}
@@ -0,0 +1,135 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+// decodeBatch decodes a batch response
+func decodeBatch(t *testing.T, data []byte) []testMessage {
+	t.Helper()
+	var batch []testMessage
+	if err := json.Unmarshal(data, &batch); err != nil {
+		t.Fatalf("Expected a batch response, got %s: %v", data, err)
+	}
+	return batch
+}
+
+func TestBatchRequest(t *testing.T) {
+	server := NewServer("test-server", "1.0.0")
+	ctx := context.Background()
+
+	response := server.handleMessage(ctx, []byte(`[
+		{"jsonrpc":"2.0","id":1,"method":"tools/list"},
+		{"jsonrpc":"2.0","method":"initialized"},
+		{"jsonrpc":"2.0","id":"two","method":"resources/list"},
+		{"jsonrpc":"2.0","id":3,"method":"no/such/method"}
+	]`))
+
+	batch := decodeBatch(t, response)
+	if len(batch) != 3 {
+		t.Fatalf("Expected 3 responses (notification omitted), got %d: %s", len(batch), response)
+	}
+	if batch[0].ID != float64(1) || batch[0].Error != nil {
+		t.Errorf("Unexpected first response: %+v", batch[0])
+	}
+	if batch[1].ID != "two" || batch[1].Error != nil {
+		t.Errorf("Unexpected second response: %+v", batch[1])
+	}
+	if batch[2].ID != float64(3) || batch[2].Error == nil || batch[2].Error.Code != MethodNotFound {
+		t.Errorf("Expected MethodNotFound for third response, got %+v", batch[2])
+	}
+}
+
+func TestBatchErrors(t *testing.T) {
+	server := NewServer("test-server", "1.0.0")
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		input string
+		code  int
+	}{
+		{"empty batch", `[]`, InvalidRequest},
+		{"invalid JSON", `[{"jsonrpc":"2.0","id":1,"method":"tools/list"},`, ParseError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp JSONRPCResponse
+			if err := json.Unmarshal(server.handleMessage(ctx, []byte(tt.input)), &resp); err != nil {
+				t.Fatalf("Expected a single error response: %v", err)
+			}
+			if resp.Error == nil || resp.Error.Code != tt.code {
+				t.Errorf("Expected error %d, got %+v", tt.code, resp.Error)
+			}
+		})
+	}
+}
+
+func TestBatchMixedInvalidEntries(t *testing.T) {
+	server := NewServer("test-server", "1.0.0")
+
+	response := server.handleMessage(context.Background(), []byte(`[
+		1,
+		{"jsonrpc":"2.0","id":1,"method":"tools/list"},
+		[{"jsonrpc":"2.0","id":2,"method":"tools/list"}],
+		{"jsonrpc":"2.0","id":3},
+		{"jsonrpc":"2.0","id":4,"result":{}}
+	]`))
+
+	batch := decodeBatch(t, response)
+	if len(batch) != 4 {
+		t.Fatalf("Expected 4 responses (client response omitted), got %d: %s", len(batch), response)
+	}
+	for _, i := range []int{0, 2, 3} {
+		if batch[i].Error == nil || batch[i].Error.Code != InvalidRequest {
+			t.Errorf("Expected InvalidRequest for entry %d, got %+v", i, batch[i])
+		}
+	}
+	if batch[1].ID != float64(1) || batch[1].Error != nil {
+		t.Errorf("Expected valid entry to succeed, got %+v", batch[1])
+	}
+	if batch[3].ID != float64(3) {
+		t.Errorf("Expected error for entry without method to carry its ID, got %+v", batch[3])
+	}
+}
+
+func TestBatchOnlyNotifications(t *testing.T) {
+	server := NewServer("test-server", "1.0.0")
+
+	response := server.handleMessage(context.Background(), []byte(`[{"jsonrpc":"2.0","method":"initialized"}]`))
+	if response != nil {
+		t.Errorf("Expected no response for a batch of notifications, got %s", response)
+	}
+}
+
+func TestBatchOverTransport(t *testing.T) {
+	client := startTestServer(t, NewServer("test-server", "1.0.0"))
+	client.initializeWith(ProtocolVersion20250326, ClientCapabilities{})
+
+	if err := client.transport.WriteMessage(context.Background(),
+		[]byte(`[{"jsonrpc":"2.0","id":1,"method":"tools/list"},{"jsonrpc":"2.0","id":2,"method":"prompts/list"}]`)); err != nil {
+		t.Fatalf("Failed to send batch: %v", err)
+	}
+
+	ctx := context.Background()
+	data, err := client.transport.ReadMessage(ctx)
+	if err != nil {
+		t.Fatalf("Failed to read batch response: %v", err)
+	}
+	if batch := decodeBatch(t, data); len(batch) != 2 {
+		t.Errorf("Expected 2 responses, got %s", data)
+	}
+}
+
+func TestBatchRejectedAfter20250618(t *testing.T) {
+	client := startTestServer(t, NewServer("test-server", "1.0.0"))
+	client.initializeWith(ProtocolVersion20250618, ClientCapabilities{})
+
+	client.send([]JSONRPCRequest{{JSONRPC: "2.0", ID: 1, Method: "tools/list"}})
+	resp := client.read()
+	if resp.Error == nil || resp.Error.Code != InvalidRequest {
+		t.Errorf("Expected InvalidRequest for a batch, got %+v", resp)
+	}
+}
@@ -0,0 +1,82 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// maxCompletionValues is the most values a completion response may hold
+const maxCompletionValues = 100
+
+// CompletionHandler suggests values for an argument of a prompt or a
+// variable of a resource template. It receives the argument name, the
+// partial value typed so far and the values of arguments already filled
+// in. Suggestions not starting with the partial value are dropped.
+type CompletionHandler func(ctx context.Context, argument, value string, arguments map[string]string) ([]string, error)
+
+// completionKey identifies the prompt or resource template a completion
+// handler serves
+func completionKey(ref CompleteReference) string {
+	if ref.Type == RefResource {
+		return ref.Type + " " + ref.URI
+	}
+	return ref.Type + " " + ref.Name
+}
+
+// RegisterCompletion registers a completion handler for a prompt
+// (CompleteReference{Type: RefPrompt, Name: ...}) or a resource template
+// (CompleteReference{Type: RefResource, URI: uriTemplate}), replacing any
+// handler registered for it before
+func (s *Server) RegisterCompletion(ref CompleteReference, handler CompletionHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.completions[completionKey(ref)] = handler
+}
+
+func (s *Server) handleComplete(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req CompleteRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	// The reference must name a registered prompt or resource template
+	s.mu.RLock()
+	var known bool
+	switch req.Ref.Type {
+	case RefPrompt:
+		_, known = s.prompts[req.Ref.Name]
+	case RefResource:
+		_, known = s.templates[req.Ref.URI]
+	}
+	handler := s.completions[completionKey(req.Ref)]
+	s.mu.RUnlock()
+	if !known {
+		return nil, &JSONRPCError{Code: InvalidParams, Message: fmt.Sprintf("Invalid params: unknown completion reference %s %s%s", req.Ref.Type, req.Ref.Name, req.Ref.URI)}
+	}
+
+	values := []string{}
+	if handler != nil {
+		var arguments map[string]string
+		if req.Context != nil {
+			arguments = req.Context.Arguments
+		}
+		candidates, err := handler(ctx, req.Argument.Name, req.Argument.Value, arguments)
+		if err != nil {
+			return nil, err
+		}
+		for _, candidate := range candidates {
+			if strings.HasPrefix(candidate, req.Argument.Value) {
+				values = append(values, candidate)
+			}
+		}
+	}
+
+	completion := Completion{Values: values, Total: len(values)}
+	if len(values) > maxCompletionValues {
+		completion.Values = values[:maxCompletionValues]
+		completion.HasMore = true
+	}
+	return CompleteResponse{Completion: completion}, nil
+}
@@ -0,0 +1,99 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	server := NewServer("test-server", "1.0.0")
+	noop := func(ctx context.Context, params json.RawMessage) (interface{}, error) { return nil, nil }
+	server.RegisterPrompt(Prompt{Name: "deploy"}, noop)
+	server.RegisterResourceTemplate(ResourceTemplate{URITemplate: "receptor://work/{unit_id}/status", Name: "status"},
+		func(ctx context.Context, uri string, vars map[string]string) (interface{}, error) { return nil, nil })
+
+	var seen map[string]string
+	server.RegisterCompletion(CompleteReference{Type: RefPrompt, Name: "deploy"},
+		func(ctx context.Context, argument, value string, arguments map[string]string) ([]string, error) {
+			seen = arguments
+			if argument != "node" {
+				return nil, nil
+			}
+			return []string{"worker-01", "worker-02", "controller"}, nil
+		})
+	server.RegisterCompletion(CompleteReference{Type: RefResource, URI: "receptor://work/{unit_id}/status"},
+		func(ctx context.Context, argument, value string, arguments map[string]string) ([]string, error) {
+			var units []string
+			for i := 0; i < 150; i++ {
+				units = append(units, fmt.Sprintf("unit%03d", i))
+			}
+			return units, nil
+		})
+
+	client := startTestServer(t, server)
+	client.initialize()
+
+	complete := func(id int, req CompleteRequest) Completion {
+		t.Helper()
+		resp := client.call(id, "completion/complete", req)
+		if resp.Error != nil {
+			t.Fatalf("completion/complete failed: %+v", resp.Error)
+		}
+		var result CompleteResponse
+		json.Unmarshal(resp.Result, &result)
+		return result.Completion
+	}
+
+	completion := complete(1, CompleteRequest{
+		Ref:      CompleteReference{Type: RefPrompt, Name: "deploy"},
+		Argument: CompleteArgument{Name: "node", Value: "work"},
+		Context:  &CompleteContext{Arguments: map[string]string{"workflow": "ai"}},
+	})
+	if len(completion.Values) != 2 || completion.Values[0] != "worker-01" || completion.HasMore {
+		t.Errorf("Expected the matching nodes, got %+v", completion)
+	}
+	if seen["workflow"] != "ai" {
+		t.Errorf("Expected the handler to see the context arguments, got %v", seen)
+	}
+
+	completion = complete(2, CompleteRequest{
+		Ref:      CompleteReference{Type: RefResource, URI: "receptor://work/{unit_id}/status"},
+		Argument: CompleteArgument{Name: "unit_id", Value: "unit"},
+	})
+	if len(completion.Values) != 100 || completion.Total != 150 || !completion.HasMore {
+		t.Errorf("Expected 100 of 150 values, got %d of %d (hasMore %v)", len(completion.Values), completion.Total, completion.HasMore)
+	}
+
+	// Arguments without suggestions complete to nothing
+	completion = complete(3, CompleteRequest{
+		Ref:      CompleteReference{Type: RefPrompt, Name: "deploy"},
+		Argument: CompleteArgument{Name: "other", Value: ""},
+	})
+	if completion.Values == nil || len(completion.Values) != 0 {
+		t.Errorf("Expected an empty completion, got %+v", completion)
+	}
+
+	resp := client.call(4, "completion/complete", CompleteRequest{
+		Ref:      CompleteReference{Type: RefPrompt, Name: "missing"},
+		Argument: CompleteArgument{Name: "node"},
+	})
+	if resp.Error == nil || resp.Error.Code != InvalidParams {
+		t.Errorf("Expected InvalidParams for an unknown prompt, got %+v", resp.Error)
+	}
+}
+
+func TestCompletionsCapabilityGatedByVersion(t *testing.T) {
+	server := NewServer("test-server", "1.0.0")
+
+	client := startTestServer(t, server)
+	if response := client.initializeWith(ProtocolVersion20241105, ClientCapabilities{}); response.Capabilities.Completions != nil {
+		t.Error("Expected no completions capability for 2024-11-05")
+	}
+
+	client = startTestServer(t, server)
+	if response := client.initializeWith(ProtocolVersion20250326, ClientCapabilities{}); response.Capabilities.Completions == nil {
+		t.Error("Expected the completions capability for 2025-03-26")
+	}
+}
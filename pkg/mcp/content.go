@@ -0,0 +1,78 @@
+package mcp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// TextContent returns a text content item
+func TextContent(text string) Content {
+	return Content{Type: "text", Text: text}
+}
+
+// ImageContent returns an image content item holding data
+func ImageContent(data []byte, mimeType string) Content {
+	return Content{Type: "image", Data: base64.StdEncoding.EncodeToString(data), MimeType: mimeType}
+}
+
+// AudioContent returns an audio content item holding data
+func AudioContent(data []byte, mimeType string) Content {
+	return Content{Type: "audio", Data: base64.StdEncoding.EncodeToString(data), MimeType: mimeType}
+}
+
+// ResourceLinkContent returns a content item pointing at a resource the
+// client can read separately
+func ResourceLinkContent(uri, name, mimeType string) Content {
+	return Content{Type: "resource_link", URI: uri, Name: name, MimeType: mimeType}
+}
+
+// EmbeddedResourceContent returns a content item embedding a resource's
+// contents in the result
+func EmbeddedResourceContent(resource ResourceContent) Content {
+	return Content{Type: "resource", Resource: &resource}
+}
+
+// toolError reports a failed tool call as a result the model can see
+func toolError(name string, err error) ToolsCallResponse {
+	return ToolsCallResponse{
+		Content: []Content{{
+			Type: "text",
+			Text: fmt.Sprintf("Error executing tool %s: %v", name, err),
+		}},
+		IsError: true,
+	}
+}
+
+// toolResult converts what a tool handler returned into a tools/call
+// result. Handlers wanting full control return a ToolsCallResponse, a
+// Content or a []Content. Strings become text; anything else is encoded as
+// JSON text, and JSON objects are also returned as structured content.
+func toolResult(result interface{}) (ToolsCallResponse, error) {
+	switch r := result.(type) {
+	case ToolsCallResponse:
+		return r, nil
+	case *ToolsCallResponse:
+		return *r, nil
+	case Content:
+		return ToolsCallResponse{Content: []Content{r}}, nil
+	case []Content:
+		return ToolsCallResponse{Content: r}, nil
+	case string:
+		return ToolsCallResponse{Content: []Content{TextContent(r)}}, nil
+	case nil:
+		return ToolsCallResponse{Content: []Content{}}, nil
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return ToolsCallResponse{}, fmt.Errorf("encoding result: %w", err)
+	}
+	response := ToolsCallResponse{Content: []Content{TextContent(string(data))}}
+
+	// Structured content must be an object
+	if len(data) > 0 && data[0] == '{' {
+		response.StructuredContent = json.RawMessage(data)
+	}
+	return response, nil
+}
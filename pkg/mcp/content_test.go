@@ -0,0 +1,86 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestToolResult(t *testing.T) {
+	type status struct {
+		State string `json:"state"`
+	}
+
+	tests := []struct {
+		name       string
+		result     interface{}
+		text       string
+		structured string
+	}{
+		{"string", "plain text", "plain text", ""},
+		{"map", map[string]int{"count": 2}, `{"count":2}`, `{"count":2}`},
+		{"struct", status{State: "Succeeded"}, `{"state":"Succeeded"}`, `{"state":"Succeeded"}`},
+		{"slice", []string{"a", "b"}, `["a","b"]`, ""},
+		{"number", 42, `42`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := toolResult(tt.result)
+			if err != nil {
+				t.Fatalf("toolResult returned error: %v", err)
+			}
+			if len(response.Content) != 1 || response.Content[0].Type != "text" || response.Content[0].Text != tt.text {
+				t.Errorf("Expected text %q, got %+v", tt.text, response.Content)
+			}
+			structured := ""
+			if response.StructuredContent != nil {
+				data, _ := json.Marshal(response.StructuredContent)
+				structured = string(data)
+			}
+			if structured != tt.structured {
+				t.Errorf("Expected structured content %q, got %q", tt.structured, structured)
+			}
+		})
+	}
+}
+
+func TestToolResultContent(t *testing.T) {
+	content := []Content{
+		ImageContent([]byte{0x89, 'P', 'N', 'G'}, "image/png"),
+		ResourceLinkContent("receptor://work/unitA/stdout", "stdout", "text/plain"),
+		EmbeddedResourceContent(ResourceContent{URI: "receptor://work/unitA/stdout", MimeType: "text/plain", Text: "output"}),
+	}
+
+	response, err := toolResult(content)
+	if err != nil {
+		t.Fatalf("toolResult returned error: %v", err)
+	}
+	if len(response.Content) != 3 || response.StructuredContent != nil {
+		t.Fatalf("Expected content to pass through unchanged, got %+v", response)
+	}
+
+	data, _ := json.Marshal(response.Content)
+	expected := `[{"type":"image","data":"iVBORw==","mimeType":"image/png"},` +
+		`{"type":"resource_link","mimeType":"text/plain","uri":"receptor://work/unitA/stdout","name":"stdout"},` +
+		`{"type":"resource","resource":{"uri":"receptor://work/unitA/stdout","mimeType":"text/plain","text":"output"}}]`
+	if string(data) != expected {
+		t.Errorf("Unexpected content encoding:\n%s", data)
+	}
+
+	single, _ := toolResult(TextContent("hi"))
+	if len(single.Content) != 1 || single.Content[0].Text != "hi" {
+		t.Errorf("Expected single content item, got %+v", single)
+	}
+}
+
+func TestToolResultEncodingError(t *testing.T) {
+	if _, err := toolResult(make(chan int)); err == nil {
+		t.Error("Expected error for a result that cannot be encoded")
+	}
+
+	response := toolError("broken", errors.New("boom"))
+	if !response.IsError || response.Content[0].Text != "Error executing tool broken: boom" {
+		t.Errorf("Unexpected error result: %+v", response)
+	}
+}
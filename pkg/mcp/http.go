@@ -0,0 +1,508 @@
+package mcp
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SessionIDHeader carries the session ID assigned at initialization
+const SessionIDHeader = "Mcp-Session-Id"
+
+// ProtocolVersionHeader carries the negotiated protocol version on requests
+// after initialization
+const ProtocolVersionHeader = "MCP-Protocol-Version"
+
+// maxHTTPMessageSize bounds the size of a POSTed JSON-RPC message
+const maxHTTPMessageSize = 4 << 20
+
+// httpOutboundBuffer is how many server-initiated messages are queued for
+// a session while no SSE stream is open
+const httpOutboundBuffer = 64
+
+// DefaultSessionIdleTimeout is how long an HTTP session may go without
+// requests or an open stream before it is closed
+const DefaultSessionIdleTimeout = 30 * time.Minute
+
+// errOutboundFull reports that a session's outbound queue overflowed
+var errOutboundFull = errors.New("outbound queue is full")
+
+// HTTPHandler serves the MCP Streamable HTTP transport on a single endpoint.
+// Clients POST JSON-RPC messages and receive JSON responses, or an SSE stream
+// carrying a request's own notifications before its response, and may GET an
+// SSE stream for other server-initiated messages.
+type HTTPHandler struct {
+	server *Server
+
+	// AllowedOrigins lists browser origins permitted to connect, in
+	// addition to localhost and the origin matching the request host
+	AllowedOrigins []string
+
+	// Token, when set, must be sent as a bearer token in the Authorization
+	// header of every request
+	Token string
+
+	// IdleTimeout closes sessions that have made no request and had no
+	// open stream for this long; zero disables it
+	IdleTimeout time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*httpSession
+}
+
+// httpSession is a session created by an HTTP initialize request
+type httpSession struct {
+	*session
+	outbound chan []byte
+	done     chan struct{}
+
+	mu        sync.Mutex
+	idle      *time.Timer
+	streaming bool
+	closed    bool
+}
+
+// NewHTTPHandler creates a Streamable HTTP handler backed by server
+func NewHTTPHandler(server *Server) *HTTPHandler {
+	return &HTTPHandler{
+		server:      server,
+		IdleTimeout: DefaultSessionIdleTimeout,
+		sessions:    make(map[string]*httpSession),
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.originAllowed(r) {
+		http.Error(w, "Forbidden origin", http.StatusForbidden)
+		return
+	}
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="mcp"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		h.handlePost(w, r)
+	case http.MethodGet:
+		h.handleGet(w, r)
+	case http.MethodDelete:
+		h.handleDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handlePost processes a client message and returns the response
+func (h *HTTPHandler) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxHTTPMessageSize+1))
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxHTTPMessageSize {
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// Batches are answered as a whole; initialize may not be batched
+	if isBatch(body) {
+		h.handleBatchPost(w, r, body)
+		return
+	}
+
+	var msg struct {
+		ID     interface{} `json:"id"`
+		Method string      `json:"method"`
+	}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		writeJSON(w, http.StatusBadRequest, h.server.encodeResponse(errorResponse(nil, ParseError, "Parse error", err.Error())))
+		return
+	}
+
+	if msg.Method == "initialize" && r.Header.Get(SessionIDHeader) == "" {
+		h.handleInitialize(w, r, body)
+		return
+	}
+
+	sess := h.lookupSession(w, r)
+	if sess == nil {
+		return
+	}
+	ctx := withSession(r.Context(), sess.session)
+
+	// Notifications and responses are acknowledged without a body
+	if msg.ID == nil || msg.Method == "" {
+		h.server.handleMessage(ctx, body)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	h.respond(ctx, w, r, body)
+}
+
+// respond handles a request or batch and writes its response. Messages the
+// server sends while handling it go on an SSE response stream, when the
+// client accepts one, so they do not depend on the GET stream.
+func (h *HTTPHandler) respond(ctx context.Context, w http.ResponseWriter, r *http.Request, body []byte) {
+	var stream *postStream
+	if acceptsEventStream(r) {
+		stream = &postStream{w: w}
+		ctx = withRequestStream(ctx, stream.send)
+	}
+
+	response := h.server.handleMessage(ctx, body)
+	if stream != nil && stream.finish(response) {
+		return
+	}
+	if response == nil {
+		// The request was cancelled by the client, or the batch held only
+		// notifications and responses
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handleBatchPost answers a JSON-RPC batch within an existing session
+func (h *HTTPHandler) handleBatchPost(w http.ResponseWriter, r *http.Request, body []byte) {
+	sess := h.lookupSession(w, r)
+	if sess == nil {
+		return
+	}
+
+	h.respond(withSession(r.Context(), sess.session), w, r, body)
+}
+
+// handleInitialize starts a new session, discarding it again if the
+// initialize request fails
+func (h *HTTPHandler) handleInitialize(w http.ResponseWriter, r *http.Request, body []byte) {
+	sess, err := h.newSession()
+	if err != nil {
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+
+	data := h.server.handleMessage(withSession(r.Context(), sess.session), body)
+	var response JSONRPCResponse
+	if json.Unmarshal(data, &response) != nil || response.Error != nil {
+		h.closeSession(sess)
+	} else {
+		w.Header().Set(SessionIDHeader, sess.id)
+	}
+	writeJSON(w, http.StatusOK, data)
+}
+
+// handleGet opens an SSE stream for server-initiated messages
+func (h *HTTPHandler) handleGet(w http.ResponseWriter, r *http.Request) {
+	if !acceptsEventStream(r) {
+		http.Error(w, "Client must accept text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+
+	sess := h.lookupSession(w, r)
+	if sess == nil {
+		return
+	}
+
+	sess.mu.Lock()
+	if sess.streaming {
+		sess.mu.Unlock()
+		http.Error(w, "Session already has an open stream", http.StatusConflict)
+		return
+	}
+	sess.streaming = true
+	sess.mu.Unlock()
+	defer func() {
+		sess.mu.Lock()
+		sess.streaming = false
+		sess.mu.Unlock()
+		h.touch(sess)
+	}()
+
+	stream, ok := newSSEWriter(w)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-sess.done:
+			return
+		case data := <-sess.outbound:
+			if err := stream.send(data); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handleDelete terminates a session at the client's request
+func (h *HTTPHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	sess := h.lookupSession(w, r)
+	if sess == nil {
+		return
+	}
+	h.closeSession(sess)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newSession creates and registers a session with a random ID
+func (h *HTTPHandler) newSession() (*httpSession, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	sess := &httpSession{
+		outbound: make(chan []byte, httpOutboundBuffer),
+		done:     make(chan struct{}),
+	}
+	sess.session = &session{
+		id:   id,
+		send: sess.enqueue,
+	}
+	if h.IdleTimeout > 0 {
+		sess.idle = time.AfterFunc(h.IdleTimeout, func() { h.expireSession(sess) })
+	}
+
+	h.mu.Lock()
+	h.sessions[sess.id] = sess
+	h.mu.Unlock()
+	h.server.addSession(sess.session)
+	return sess, nil
+}
+
+// Close ends every session and its stream. Requests already being handled
+// run to completion; register it with http.Server.RegisterOnShutdown so
+// open streams do not hold up a graceful shutdown.
+func (h *HTTPHandler) Close() {
+	h.mu.Lock()
+	sessions := make([]*httpSession, 0, len(h.sessions))
+	for _, sess := range h.sessions {
+		sessions = append(sessions, sess)
+	}
+	h.mu.Unlock()
+
+	for _, sess := range sessions {
+		h.closeSession(sess)
+	}
+}
+
+// touch restarts a session's idle timer
+func (h *HTTPHandler) touch(sess *httpSession) {
+	if sess.idle != nil {
+		sess.idle.Reset(h.IdleTimeout)
+	}
+}
+
+// expireSession closes a session that has been idle for IdleTimeout,
+// unless it has an open stream
+func (h *HTTPHandler) expireSession(sess *httpSession) {
+	sess.mu.Lock()
+	streaming := sess.streaming
+	sess.mu.Unlock()
+	if streaming {
+		h.touch(sess)
+		return
+	}
+	h.closeSession(sess)
+}
+
+// lookupSession finds the session named by the request header, writing
+// the appropriate error response when it is missing or unknown
+func (h *HTTPHandler) lookupSession(w http.ResponseWriter, r *http.Request) *httpSession {
+	id := r.Header.Get(SessionIDHeader)
+	if id == "" {
+		http.Error(w, "Missing "+SessionIDHeader+" header", http.StatusBadRequest)
+		return nil
+	}
+
+	h.mu.Lock()
+	sess, exists := h.sessions[id]
+	h.mu.Unlock()
+	if !exists {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return nil
+	}
+
+	// Clients without the header are assumed to speak 2025-03-26
+	if version := r.Header.Get(ProtocolVersionHeader); version != "" && !supportedVersion(version) {
+		http.Error(w, "Unsupported "+ProtocolVersionHeader+": "+version, http.StatusBadRequest)
+		return nil
+	}
+	h.touch(sess)
+	return sess
+}
+
+// closeSession forgets a session and ends its stream
+func (h *HTTPHandler) closeSession(sess *httpSession) {
+	h.mu.Lock()
+	delete(h.sessions, sess.id)
+	h.mu.Unlock()
+	h.server.removeSession(sess.id)
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if !sess.closed {
+		sess.closed = true
+		close(sess.done)
+		if sess.idle != nil {
+			sess.idle.Stop()
+		}
+	}
+}
+
+// enqueue queues a server-initiated message for the session's GET stream.
+// Messages are dropped while the queue is full, since the client may have
+// stopped reading its stream or never opened one.
+func (s *httpSession) enqueue(data []byte) error {
+	select {
+	case <-s.done:
+		return fmt.Errorf("session %s is closed", s.id)
+	default:
+	}
+
+	select {
+	case s.outbound <- data:
+		return nil
+	default:
+		return fmt.Errorf("session %s: %w", s.id, errOutboundFull)
+	}
+}
+
+// authorized checks the request's bearer token when Token is set
+func (h *HTTPHandler) authorized(r *http.Request) bool {
+	if h.Token == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(h.Token)) == 1
+}
+
+// originAllowed guards against DNS rebinding by rejecting browser requests
+// from origins other than localhost, the request host and AllowedOrigins
+func (h *HTTPHandler) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range h.AllowedOrigins {
+		if origin == allowed {
+			return true
+		}
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" || net.ParseIP(host).IsLoopback() {
+		return true
+	}
+	return u.Host == r.Host
+}
+
+// acceptsEventStream reports whether the client accepts SSE responses
+func acceptsEventStream(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		if strings.Contains(accept, "text/event-stream") {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, data []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// postStream is the SSE response to a POSTed request, started when the
+// server first sends a message while handling the request
+type postStream struct {
+	mu       sync.Mutex
+	w        http.ResponseWriter
+	sse      *sseWriter
+	finished bool
+}
+
+// send writes a message belonging to the request to the stream
+func (p *postStream) send(data []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.finished {
+		return errors.New("request already answered")
+	}
+	if p.sse == nil {
+		sse, ok := newSSEWriter(p.w)
+		if !ok {
+			return errors.New("streaming not supported")
+		}
+		p.sse = sse
+	}
+	return p.sse.send(data)
+}
+
+// finish ends the stream with the response, if any. It reports whether the
+// stream was started; if not, the response is still to be written.
+func (p *postStream) finish(response []byte) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.finished = true
+	if p.sse == nil {
+		return false
+	}
+	if response != nil {
+		p.sse.send(response)
+	}
+	return true
+}
+
+// sseWriter writes JSON-RPC messages as server-sent events
+type sseWriter struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// newSSEWriter starts an event stream response
+func newSSEWriter(w http.ResponseWriter) (*sseWriter, bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, false
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	return &sseWriter{w: w, flusher: flusher}, true
+}
+
+// send writes one message event
+func (s *sseWriter) send(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := fmt.Fprintf(s.w, "event: message\ndata: %s\n\n", data); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
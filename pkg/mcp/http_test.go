@@ -0,0 +1,403 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// postMessage POSTs a JSON-RPC message to the test server
+func postMessage(t *testing.T, url, sessionID string, msg interface{}) *http.Response {
+	t.Helper()
+	body, _ := json.Marshal(msg)
+	req, _ := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if sessionID != "" {
+		req.Header.Set(SessionIDHeader, sessionID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	return resp
+}
+
+// initializeHTTP runs the initialize handshake and returns the session ID
+func initializeHTTP(t *testing.T, url string) string {
+	t.Helper()
+	return initializeHTTPVersion(t, url, MCPVersion)
+}
+
+// initializeHTTPVersion runs the initialize handshake requesting version
+func initializeHTTPVersion(t *testing.T, url, version string) string {
+	t.Helper()
+	resp := postMessage(t, url, "", JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "initialize",
+		Params: InitializeRequest{
+			ProtocolVersion: version,
+			ClientInfo:      ClientInfo{Name: "http-client", Version: "1.0.0"},
+		},
+	})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200 for initialize, got %d", resp.StatusCode)
+	}
+	sessionID := resp.Header.Get(SessionIDHeader)
+	if sessionID == "" {
+		t.Fatal("Expected initialize to assign a session ID")
+	}
+	return sessionID
+}
+
+func newHTTPTestServer(t *testing.T) (*Server, *httptest.Server) {
+	server := NewServer("test-server", "1.0.0")
+	server.RegisterTool(Tool{Name: "test_tool", InputSchema: map[string]interface{}{"type": "object"}},
+		func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			return "success", nil
+		})
+	ts := httptest.NewServer(NewHTTPHandler(server))
+	t.Cleanup(ts.Close)
+	return server, ts
+}
+
+func TestHTTPHandlerRequests(t *testing.T) {
+	_, ts := newHTTPTestServer(t)
+	sessionID := initializeHTTP(t, ts.URL)
+
+	resp := postMessage(t, ts.URL, sessionID, JSONRPCRequest{JSONRPC: "2.0", ID: 2, Method: "tools/list"})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected application/json, got %s", ct)
+	}
+
+	var response struct {
+		ID     int               `json:"id"`
+		Result ToolsListResponse `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.ID != 2 || len(response.Result.Tools) != 1 {
+		t.Errorf("Unexpected tools/list response: %+v", response)
+	}
+}
+
+func TestHTTPHandlerNotificationAccepted(t *testing.T) {
+	_, ts := newHTTPTestServer(t)
+	sessionID := initializeHTTP(t, ts.URL)
+
+	resp := postMessage(t, ts.URL, sessionID, JSONRPCNotification{JSONRPC: "2.0", Method: "initialized"})
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("Expected 202 for notification, got %d", resp.StatusCode)
+	}
+}
+
+func TestHTTPHandlerSessionErrors(t *testing.T) {
+	_, ts := newHTTPTestServer(t)
+
+	resp := postMessage(t, ts.URL, "", JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "tools/list"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 without session, got %d", resp.StatusCode)
+	}
+
+	resp = postMessage(t, ts.URL, "unknown", JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "tools/list"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown session, got %d", resp.StatusCode)
+	}
+
+	sessionID := initializeHTTP(t, ts.URL)
+	body, _ := json.Marshal(JSONRPCRequest{JSONRPC: "2.0", ID: 2, Method: "tools/list"})
+	req, _ := http.NewRequest(http.MethodPost, ts.URL, bytes.NewReader(body))
+	req.Header.Set(SessionIDHeader, sessionID)
+	req.Header.Set(ProtocolVersionHeader, "1999-01-01")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unsupported protocol version, got %d", resp.StatusCode)
+	}
+
+	// A failed initialize does not leave a session behind
+	resp = postMessage(t, ts.URL, "", JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "initialize", Params: "bogus"})
+	resp.Body.Close()
+	if resp.Header.Get(SessionIDHeader) != "" {
+		t.Error("Expected no session ID for failed initialize")
+	}
+}
+
+func TestHTTPHandlerDeleteSession(t *testing.T) {
+	_, ts := newHTTPTestServer(t)
+	sessionID := initializeHTTP(t, ts.URL)
+
+	req, _ := http.NewRequest(http.MethodDelete, ts.URL, nil)
+	req.Header.Set(SessionIDHeader, sessionID)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("DELETE failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", resp.StatusCode)
+	}
+
+	resp = postMessage(t, ts.URL, sessionID, JSONRPCRequest{JSONRPC: "2.0", ID: 2, Method: "tools/list"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 after DELETE, got %d", resp.StatusCode)
+	}
+}
+
+func TestHTTPHandlerEventStream(t *testing.T) {
+	server, ts := newHTTPTestServer(t)
+	sessionID := initializeHTTP(t, ts.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set(SessionIDHeader, sessionID)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Expected text/event-stream, got %s", ct)
+	}
+
+	// Deliver a server-initiated message through the session
+	server.mu.RLock()
+	sess := server.sessions[sessionID]
+	server.mu.RUnlock()
+	if err := sess.send([]byte(`{"jsonrpc":"2.0","method":"test/event"}`)); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "data: ") {
+			if !strings.Contains(line, "test/event") {
+				t.Errorf("Unexpected event data: %s", line)
+			}
+			return
+		}
+	}
+	t.Fatalf("Stream ended without an event: %v", scanner.Err())
+}
+
+func TestHTTPHandlerCloseEndsStreams(t *testing.T) {
+	server := NewServer("test-server", "1.0.0")
+	handler := NewHTTPHandler(server)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	sessionID := initializeHTTP(t, ts.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set(SessionIDHeader, sessionID)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	handler.Close()
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("Expected the stream to end, got %v", err)
+	}
+
+	resp = postMessage(t, ts.URL, sessionID, JSONRPCRequest{JSONRPC: "2.0", ID: 2, Method: "ping"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 after Close, got %d", resp.StatusCode)
+	}
+}
+
+func TestHTTPHandlerRejectsForeignOrigin(t *testing.T) {
+	_, ts := newHTTPTestServer(t)
+
+	body, _ := json.Marshal(JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "initialize"})
+	req, _ := http.NewRequest(http.MethodPost, ts.URL, bytes.NewReader(body))
+	req.Header.Set("Origin", "https://evil.example.com")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 for foreign origin, got %d", resp.StatusCode)
+	}
+}
+
+func TestHTTPHandlerBatch(t *testing.T) {
+	_, ts := newHTTPTestServer(t)
+	sessionID := initializeHTTPVersion(t, ts.URL, ProtocolVersion20250326)
+
+	resp := postMessage(t, ts.URL, sessionID, []JSONRPCRequest{
+		{JSONRPC: "2.0", ID: 2, Method: "tools/list"},
+		{JSONRPC: "2.0", ID: 3, Method: "resources/list"},
+	})
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200 for batch, got %d", resp.StatusCode)
+	}
+	var batch []JSONRPCResponse
+	if err := json.NewDecoder(resp.Body).Decode(&batch); err != nil || len(batch) != 2 {
+		t.Errorf("Expected 2 batched responses, got %+v, %v", batch, err)
+	}
+
+	resp = postMessage(t, ts.URL, sessionID, []JSONRPCNotification{{JSONRPC: "2.0", Method: "initialized"}})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("Expected 202 for a batch of notifications, got %d", resp.StatusCode)
+	}
+}
+
+func TestHTTPHandlerRequiresToken(t *testing.T) {
+	server := NewServer("test-server", "1.0.0")
+	handler := NewHTTPHandler(server)
+	handler.Token = "s3cret"
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	tests := map[string]int{
+		"":              http.StatusUnauthorized,
+		"Bearer wrong":  http.StatusUnauthorized,
+		"Basic s3cret":  http.StatusUnauthorized,
+		"Bearer s3cret": http.StatusOK,
+	}
+	for authorization, want := range tests {
+		body, _ := json.Marshal(JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "initialize", Params: InitializeRequest{ProtocolVersion: MCPVersion}})
+		req, _ := http.NewRequest(http.MethodPost, ts.URL, bytes.NewReader(body))
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("POST failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("Authorization %q: expected %d, got %d", authorization, want, resp.StatusCode)
+		}
+	}
+}
+
+func TestHTTPHandlerExpiresIdleSessions(t *testing.T) {
+	server := NewServer("test-server", "1.0.0")
+	handler := NewHTTPHandler(server)
+	handler.IdleTimeout = 50 * time.Millisecond
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	sessionID := initializeHTTP(t, ts.URL)
+
+	time.Sleep(200 * time.Millisecond)
+
+	resp := postMessage(t, ts.URL, sessionID, JSONRPCRequest{JSONRPC: "2.0", ID: 2, Method: "ping"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for an expired session, got %d", resp.StatusCode)
+	}
+	server.mu.RLock()
+	defer server.mu.RUnlock()
+	if _, exists := server.sessions[sessionID]; exists {
+		t.Error("Expected the expired session to be removed from the server")
+	}
+}
+
+func TestHTTPHandlerDropsOverflowingMessages(t *testing.T) {
+	server, ts := newHTTPTestServer(t)
+	sessionID := initializeHTTP(t, ts.URL)
+
+	server.mu.RLock()
+	sess := server.sessions[sessionID]
+	server.mu.RUnlock()
+
+	// Nobody reads the stream, so the queue fills and messages are dropped
+	var err error
+	for i := 0; i <= httpOutboundBuffer && err == nil; i++ {
+		err = sess.send([]byte(`{"jsonrpc":"2.0","method":"test/event"}`))
+	}
+	if err == nil {
+		t.Fatal("Expected the outbound queue to overflow")
+	}
+
+	resp := postMessage(t, ts.URL, sessionID, JSONRPCRequest{JSONRPC: "2.0", ID: 2, Method: "ping"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the session to survive the overflow, got %d", resp.StatusCode)
+	}
+}
+
+func TestHTTPHandlerStreamsRequestNotifications(t *testing.T) {
+	const updates = 2 * httpOutboundBuffer
+	server, ts := newHTTPTestServer(t)
+	server.RegisterTool(Tool{Name: "slow_tool", InputSchema: map[string]interface{}{"type": "object"}},
+		func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			for i := 1; i <= updates; i++ {
+				ReportProgress(ctx, float64(i), updates, "")
+			}
+			return "done", nil
+		})
+	sessionID := initializeHTTP(t, ts.URL)
+
+	// No GET stream is open; the progress comes with the response
+	resp := postMessage(t, ts.URL, sessionID, JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      2,
+		Method:  "tools/call",
+		Params:  map[string]interface{}{"name": "slow_tool", "_meta": map[string]interface{}{"progressToken": "token-1"}},
+	})
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Expected text/event-stream, got %s", ct)
+	}
+
+	var messages []JSONRPCResponse
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+			var msg JSONRPCResponse
+			json.Unmarshal([]byte(data), &msg)
+			messages = append(messages, msg)
+		}
+	}
+	if len(messages) != updates+1 {
+		t.Fatalf("Expected %d progress notifications and the response, got %d messages", updates, len(messages))
+	}
+	if last := messages[updates]; last.ID != float64(2) || last.Error != nil {
+		t.Errorf("Expected the response last, got %+v", last)
+	}
+
+	resp = postMessage(t, ts.URL, sessionID, JSONRPCRequest{JSONRPC: "2.0", ID: 3, Method: "ping"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the session to survive, got %d", resp.StatusCode)
+	}
+}
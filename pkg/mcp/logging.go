@@ -0,0 +1,119 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// logSeverity orders the logging levels from least to most severe
+var logSeverity = map[LoggingLevel]int{
+	LogLevelDebug:     0,
+	LogLevelInfo:      1,
+	LogLevelNotice:    2,
+	LogLevelWarning:   3,
+	LogLevelError:     4,
+	LogLevelCritical:  5,
+	LogLevelAlert:     6,
+	LogLevelEmergency: 7,
+}
+
+// atLeast reports whether level is as severe as min
+func (level LoggingLevel) atLeast(min LoggingLevel) bool {
+	return logSeverity[level] >= logSeverity[min]
+}
+
+// Logger writes leveled messages under a name such as "mcp.transport" or
+// "receptor.work". Messages go to stderr at or above the server's log level,
+// and to every initialized client as notifications/message at or above the
+// level the client chose with logging/setLevel. Clients that have not set a
+// level are sent no messages.
+type Logger struct {
+	name   string
+	server *Server
+}
+
+// Logger returns a logger that writes under name
+func (s *Server) Logger(name string) *Logger {
+	return &Logger{name: name, server: s}
+}
+
+// Log writes a message at level
+func (l *Logger) Log(level LoggingLevel, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if level.atLeast(l.server.logLevel) {
+		l.server.stderr.Printf("[%s] %s: %s", l.name, strings.ToUpper(string(level)), message)
+	}
+	l.server.broadcastLog(LoggingMessageNotification{Level: level, Logger: l.name, Data: message})
+}
+
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.Log(LogLevelDebug, format, args...)
+}
+
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.Log(LogLevelInfo, format, args...)
+}
+
+func (l *Logger) Noticef(format string, args ...interface{}) {
+	l.Log(LogLevelNotice, format, args...)
+}
+
+func (l *Logger) Warningf(format string, args ...interface{}) {
+	l.Log(LogLevelWarning, format, args...)
+}
+
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.Log(LogLevelError, format, args...)
+}
+
+// setLogLevel records the minimum level the client wants to be sent
+func (sess *session) setLogLevel(level LoggingLevel) {
+	sess.logLevelMu.Lock()
+	defer sess.logLevelMu.Unlock()
+	sess.logLevel = level
+}
+
+// wantsLog reports whether the client asked for messages at level
+func (sess *session) wantsLog(level LoggingLevel) bool {
+	sess.logLevelMu.Lock()
+	defer sess.logLevelMu.Unlock()
+	return sess.logLevel != "" && level.atLeast(sess.logLevel)
+}
+
+// broadcastLog sends a log message to every initialized client that asked
+// for its level. Delivery failures go to stderr only, so they cannot loop.
+func (s *Server) broadcastLog(message LoggingMessageNotification) {
+	s.mu.RLock()
+	var recipients []*session
+	for _, sess := range s.sessions {
+		if sess.initialized.Load() && sess.wantsLog(message.Level) {
+			recipients = append(recipients, sess)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, sess := range recipients {
+		if err := sess.notify("notifications/message", message); err != nil {
+			s.stderr.Printf("[mcp.server] ERROR: sending log message to session %s: %v", sess.id, err)
+		}
+	}
+}
+
+func (s *Server) handleLoggingSetLevel(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req SetLevelRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+	if _, known := logSeverity[req.Level]; !known {
+		return nil, &JSONRPCError{Code: InvalidParams, Message: fmt.Sprintf("Invalid params: unknown logging level %q", req.Level)}
+	}
+
+	sess := sessionFromContext(ctx)
+	if sess == nil {
+		return nil, fmt.Errorf("logging/setLevel requires a session")
+	}
+	sess.setLogLevel(req.Level)
+	return struct{}{}, nil
+}
@@ -0,0 +1,67 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestLoggingSetLevel(t *testing.T) {
+	server := NewServer("test-server", "1.0.0")
+	var stderr bytes.Buffer
+	server.stderr = log.New(&stderr, "", 0)
+	client := startTestServer(t, server)
+	client.initialize()
+	logger := server.Logger("receptor.work")
+
+	resp := client.call(1, "logging/setLevel", SetLevelRequest{Level: "verbose"})
+	if resp.Error == nil || resp.Error.Code != InvalidParams {
+		t.Errorf("Expected InvalidParams for an unknown level, got %+v", resp.Error)
+	}
+
+	// Nothing is sent before the client sets a level
+	logger.Errorf("before setLevel")
+
+	resp = client.call(2, "logging/setLevel", SetLevelRequest{Level: LogLevelWarning})
+	if resp.Error != nil {
+		t.Fatalf("logging/setLevel failed: %+v", resp.Error)
+	}
+
+	logger.Infof("below the client's level")
+	logger.Warningf("unit %s failed", "abc123")
+
+	msg := client.read()
+	if msg.Method != "notifications/message" {
+		t.Fatalf("Expected notifications/message, got %+v", msg)
+	}
+	var notification LoggingMessageNotification
+	json.Unmarshal(msg.Params, &notification)
+	if notification.Level != LogLevelWarning || notification.Logger != "receptor.work" || notification.Data != "unit abc123 failed" {
+		t.Errorf("Unexpected log notification: %+v", notification)
+	}
+
+	// Operators still see messages at the server's level on stderr
+	output := stderr.String()
+	for _, expected := range []string{"[receptor.work] ERROR: before setLevel", "[receptor.work] INFO: below the client's level"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected stderr to contain %q, got:\n%s", expected, output)
+		}
+	}
+}
+
+func TestLogLevelFiltersStderr(t *testing.T) {
+	server := NewServer("test-server", "1.0.0", WithLogLevel(LogLevelWarning))
+	var stderr bytes.Buffer
+	server.stderr = log.New(&stderr, "", 0)
+
+	logger := server.Logger("mcp.transport")
+	logger.Debugf("debug detail")
+	logger.Infof("routine event")
+	logger.Errorf("write failed")
+
+	if stderr.String() != "[mcp.transport] ERROR: write failed\n" {
+		t.Errorf("Unexpected stderr output: %q", stderr.String())
+	}
+}
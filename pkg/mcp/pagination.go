@@ -0,0 +1,56 @@
+package mcp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+)
+
+// paginate returns the page of keys that follows cursor, in sorted order,
+// and the cursor for the page after it. The returned cursor is empty on
+// the last page. A cursor names the last key of the previous page, so
+// listings stay stable while entries are added or removed.
+func paginate(keys []string, cursor string, pageSize int) ([]string, string, error) {
+	sort.Strings(keys)
+
+	start := 0
+	if cursor != "" {
+		after, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			return nil, "", &JSONRPCError{Code: InvalidParams, Message: "Invalid cursor", Data: cursor}
+		}
+		start = sort.Search(len(keys), func(i int) bool { return keys[i] > string(after) })
+	}
+
+	end := len(keys)
+	if pageSize > 0 && start+pageSize < end {
+		end = start + pageSize
+	}
+	page := keys[start:end]
+
+	nextCursor := ""
+	if end < len(keys) {
+		nextCursor = base64.RawURLEncoding.EncodeToString([]byte(page[len(page)-1]))
+	}
+	return page, nextCursor, nil
+}
+
+// mapKeys returns the keys of m in no particular order
+func mapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// parseParams decodes optional request params into v
+func parseParams(params json.RawMessage, v interface{}) error {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &JSONRPCError{Code: InvalidParams, Message: "Invalid params", Data: err.Error()}
+	}
+	return nil
+}
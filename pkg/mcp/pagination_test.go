@@ -0,0 +1,87 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestToolsListPagination(t *testing.T) {
+	server := NewServer("test-server", "1.0.0", WithPageSize(2))
+	for _, name := range []string{"echo", "alpha", "delta", "charlie", "bravo"} {
+		server.RegisterTool(Tool{Name: name}, func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			return nil, nil
+		})
+	}
+	ctx := context.Background()
+
+	var names []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("Pagination did not terminate")
+		}
+		params, _ := json.Marshal(ToolsListRequest{Cursor: cursor})
+		result, err := server.handleToolsList(ctx, params)
+		if err != nil {
+			t.Fatalf("tools/list returned error: %v", err)
+		}
+		page := result.(ToolsListResponse)
+		if len(page.Tools) > 2 {
+			t.Fatalf("Expected at most 2 tools per page, got %d", len(page.Tools))
+		}
+		for _, tool := range page.Tools {
+			names = append(names, tool.Name)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	expected := []string{"alpha", "bravo", "charlie", "delta", "echo"}
+	if fmt.Sprint(names) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+}
+
+func TestListPaginationStableAcrossChanges(t *testing.T) {
+	server := NewServer("test-server", "1.0.0", WithPageSize(2))
+	for _, uri := range []string{"receptor://a", "receptor://b", "receptor://c"} {
+		server.RegisterResource(Resource{URI: uri, Name: uri}, nil)
+	}
+	ctx := context.Background()
+
+	result, _ := server.handleResourcesList(ctx, nil)
+	first := result.(ResourcesListResponse)
+	if len(first.Resources) != 2 || first.NextCursor == "" {
+		t.Fatalf("Unexpected first page: %+v", first)
+	}
+
+	// An entry added before the cursor does not shift the next page
+	server.RegisterResource(Resource{URI: "receptor://0", Name: "zero"}, nil)
+	params, _ := json.Marshal(ResourcesListRequest{Cursor: first.NextCursor})
+	result, err := server.handleResourcesList(ctx, params)
+	if err != nil {
+		t.Fatalf("resources/list returned error: %v", err)
+	}
+	second := result.(ResourcesListResponse)
+	if len(second.Resources) != 1 || second.Resources[0].URI != "receptor://c" || second.NextCursor != "" {
+		t.Errorf("Unexpected second page: %+v", second)
+	}
+}
+
+func TestListInvalidCursor(t *testing.T) {
+	server := NewServer("test-server", "1.0.0")
+
+	resp := server.handleRequest(context.Background(), JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "prompts/list",
+		Params:  PromptsListRequest{Cursor: "not a cursor!"},
+	})
+	if resp.Error == nil || resp.Error.Code != InvalidParams {
+		t.Errorf("Expected InvalidParams for a bad cursor, got %+v", resp.Error)
+	}
+}
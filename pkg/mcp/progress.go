@@ -0,0 +1,66 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// progressReporter sends progress notifications for one request
+type progressReporter struct {
+	sess  *session
+	token interface{}
+
+	mu   sync.Mutex
+	last float64
+	sent bool
+}
+
+type progressContextKey struct{}
+
+// withProgressToken attaches a progress reporter to ctx when the request
+// params carry _meta.progressToken
+func withProgressToken(ctx context.Context, params json.RawMessage) context.Context {
+	sess := sessionFromContext(ctx)
+	if sess == nil {
+		return ctx
+	}
+
+	var envelope struct {
+		Meta *RequestMeta `json:"_meta"`
+	}
+	if err := json.Unmarshal(params, &envelope); err != nil || envelope.Meta == nil || envelope.Meta.ProgressToken == nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, progressContextKey{}, &progressReporter{
+		sess:  sess,
+		token: envelope.Meta.ProgressToken,
+	})
+}
+
+// ReportProgress sends a notifications/progress message for the request
+// being handled with ctx. Total may be zero when it is unknown. It does
+// nothing if the client did not ask for progress, and drops updates whose
+// progress does not increase, since clients expect it to grow monotonically.
+func ReportProgress(ctx context.Context, progress, total float64, message string) error {
+	reporter, ok := ctx.Value(progressContextKey{}).(*progressReporter)
+	if !ok {
+		return nil
+	}
+
+	reporter.mu.Lock()
+	defer reporter.mu.Unlock()
+	if reporter.sent && progress <= reporter.last {
+		return nil
+	}
+	reporter.last = progress
+	reporter.sent = true
+
+	return reporter.sess.notifyFor(ctx, "notifications/progress", ProgressNotification{
+		ProgressToken: reporter.token,
+		Progress:      progress,
+		Total:         total,
+		Message:       message,
+	})
+}
@@ -0,0 +1,79 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestReportProgress(t *testing.T) {
+	server := NewServer("test-server", "1.0.0")
+	server.RegisterTool(Tool{Name: "slow", InputSchema: map[string]interface{}{"type": "object"}},
+		func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			ReportProgress(ctx, 1, 3, "Pending")
+			ReportProgress(ctx, 1, 3, "duplicate")
+			ReportProgress(ctx, 2, 3, "Running")
+			ReportProgress(ctx, 3, 3, "Succeeded")
+			return "done", nil
+		})
+
+	client := startTestServer(t, server)
+	client.initialize()
+
+	client.send(JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "tools/call", Params: ToolsCallRequest{
+		Name: "slow",
+		Meta: &RequestMeta{ProgressToken: "token-1"},
+	}})
+
+	var messages []string
+	for {
+		msg := client.read()
+		if msg.Method == "" {
+			if msg.ID != float64(1) || msg.Error != nil {
+				t.Fatalf("Unexpected response: %+v", msg)
+			}
+			break
+		}
+		if msg.Method != "notifications/progress" {
+			t.Fatalf("Unexpected notification: %s", msg.Method)
+		}
+		var progress ProgressNotification
+		if err := json.Unmarshal(msg.Params, &progress); err != nil {
+			t.Fatalf("Failed to decode progress: %v", err)
+		}
+		if progress.ProgressToken != "token-1" || progress.Total != 3 {
+			t.Errorf("Unexpected progress: %+v", progress)
+		}
+		messages = append(messages, progress.Message)
+	}
+
+	expected := []string{"Pending", "Running", "Succeeded"}
+	if len(messages) != len(expected) {
+		t.Fatalf("Expected progress %v, got %v", expected, messages)
+	}
+	for i := range expected {
+		if messages[i] != expected[i] {
+			t.Errorf("Expected progress %v, got %v", expected, messages)
+		}
+	}
+}
+
+func TestReportProgressWithoutToken(t *testing.T) {
+	server := NewServer("test-server", "1.0.0")
+	server.RegisterTool(Tool{Name: "slow", InputSchema: map[string]interface{}{"type": "object"}},
+		func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			if err := ReportProgress(ctx, 1, 0, "ignored"); err != nil {
+				return nil, err
+			}
+			return "done", nil
+		})
+
+	client := startTestServer(t, server)
+	client.initialize()
+
+	// The response is the next message, with no progress before it
+	resp := client.call(1, "tools/call", ToolsCallRequest{Name: "slow"})
+	if resp.Error != nil {
+		t.Errorf("Unexpected error: %+v", resp.Error)
+	}
+}
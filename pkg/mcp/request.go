@@ -0,0 +1,111 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrElicitationUnsupported is returned by Elicit when the client cannot be
+// asked for input
+var ErrElicitationUnsupported = errors.New("client does not support elicitation")
+
+// clientResponse is a client's answer to a server-initiated request
+type clientResponse struct {
+	ID     interface{}     `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *JSONRPCError   `json:"error"`
+}
+
+// request sends a server-initiated request to the client and waits for its
+// response. If ctx ends first the client is told the request was cancelled.
+func (sess *session) request(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	id := fmt.Sprintf("server-%d", sess.nextRequestID.Add(1))
+	key := requestKey(id)
+	reply := make(chan clientResponse, 1)
+
+	sess.pendingMu.Lock()
+	if sess.pending == nil {
+		sess.pending = make(map[string]chan clientResponse)
+	}
+	sess.pending[key] = reply
+	sess.pendingMu.Unlock()
+	defer func() {
+		sess.pendingMu.Lock()
+		delete(sess.pending, key)
+		sess.pendingMu.Unlock()
+	}()
+
+	data, err := json.Marshal(JSONRPCRequest{JSONRPC: "2.0", ID: id, Method: method, Params: params})
+	if err != nil {
+		return nil, err
+	}
+	if err := sess.sendFor(ctx, data); err != nil {
+		return nil, err
+	}
+
+	select {
+	case response := <-reply:
+		if response.Error != nil {
+			return nil, response.Error
+		}
+		return response.Result, nil
+	case <-ctx.Done():
+		sess.notify("notifications/cancelled", CancelledNotification{RequestID: id, Reason: ctx.Err().Error()})
+		return nil, ctx.Err()
+	}
+}
+
+// deliver hands a client response to the request awaiting it, reporting
+// whether one was
+func (sess *session) deliver(response clientResponse) bool {
+	sess.pendingMu.Lock()
+	defer sess.pendingMu.Unlock()
+	reply, exists := sess.pending[requestKey(response.ID)]
+	if !exists {
+		return false
+	}
+	delete(sess.pending, requestKey(response.ID))
+	reply <- response
+	return true
+}
+
+// handleResponse routes a response from the client to the server-initiated
+// request it answers
+func (s *Server) handleResponse(ctx context.Context, data []byte) {
+	var response clientResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		s.transportLog.Warningf("Ignoring malformed response from client: %v", err)
+		return
+	}
+	sess := sessionFromContext(ctx)
+	if sess == nil || !sess.deliver(response) {
+		s.transportLog.Warningf("Ignoring response to unknown request %v", response.ID)
+	}
+}
+
+// Elicit asks the client's user for input matching schema, a flat object
+// schema with primitive properties, and returns their answer. It fails with
+// ErrElicitationUnsupported unless the session negotiated 2025-06-18 or
+// later and the client declared the elicitation capability.
+func Elicit(ctx context.Context, message string, schema map[string]interface{}) (*ElicitResult, error) {
+	sess := sessionFromContext(ctx)
+	if sess == nil {
+		return nil, ErrElicitationUnsupported
+	}
+	version, capabilities := sess.negotiated()
+	if version < ProtocolVersion20250618 || capabilities.Elicitation == nil {
+		return nil, ErrElicitationUnsupported
+	}
+
+	data, err := sess.request(ctx, "elicitation/create", ElicitRequest{Message: message, RequestedSchema: schema})
+	if err != nil {
+		return nil, err
+	}
+	var result ElicitResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("invalid elicitation result: %w", err)
+	}
+	return &result, nil
+}
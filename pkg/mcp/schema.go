@@ -0,0 +1,259 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SchemaViolation is one way a value fails to match a JSON Schema
+type SchemaViolation struct {
+	// Pointer is the JSON pointer (RFC 6901) of the offending value
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+}
+
+// normalizeSchema converts a schema built from Go values, such as
+// []string enums, into the generic form encoding/json produces, so the
+// validator only has to handle one representation
+func normalizeSchema(schema map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	var normalized map[string]interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// ValidateSchema checks a decoded JSON value against a JSON Schema and
+// returns every violation found. It supports the keywords tool input
+// schemas use: type, required, properties, additionalProperties, items,
+// enum, const, pattern, minLength/maxLength, minimum/maximum,
+// exclusiveMinimum/exclusiveMaximum and minItems/maxItems. Unknown
+// keywords are ignored.
+func ValidateSchema(schema map[string]interface{}, value interface{}) []SchemaViolation {
+	normalized, err := normalizeSchema(schema)
+	if err != nil {
+		return []SchemaViolation{{Message: fmt.Sprintf("invalid schema: %v", err)}}
+	}
+	var violations []SchemaViolation
+	validateValue(normalized, value, "", &violations)
+	return violations
+}
+
+// validateValue appends the violations of value at pointer to violations
+func validateValue(schema map[string]interface{}, value interface{}, pointer string, violations *[]SchemaViolation) {
+	fail := func(format string, args ...interface{}) {
+		*violations = append(*violations, SchemaViolation{Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if types := schemaTypes(schema["type"]); len(types) > 0 && !matchesAnyType(types, value) {
+		fail("expected %s, got %s", strings.Join(types, " or "), jsonType(value))
+		// Further checks would only repeat the type mismatch
+		return
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok && !containsValue(enum, value) {
+		fail("must be one of %s", encodeValue(enum))
+	}
+	if constant, ok := schema["const"]; ok && !jsonEqual(constant, value) {
+		fail("must be %s", encodeValue(constant))
+	}
+
+	switch v := value.(type) {
+	case string:
+		validateString(schema, v, fail)
+	case float64:
+		validateNumber(schema, v, fail)
+	case map[string]interface{}:
+		validateObject(schema, v, pointer, violations, fail)
+	case []interface{}:
+		validateArray(schema, v, pointer, violations, fail)
+	}
+}
+
+func validateString(schema map[string]interface{}, s string, fail func(string, ...interface{})) {
+	length := float64(len([]rune(s)))
+	if min, ok := schema["minLength"].(float64); ok && length < min {
+		fail("must be at least %v characters long", min)
+	}
+	if max, ok := schema["maxLength"].(float64); ok && length > max {
+		fail("must be at most %v characters long", max)
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			fail("schema pattern %q is invalid: %v", pattern, err)
+		} else if !re.MatchString(s) {
+			fail("must match pattern %q", pattern)
+		}
+	}
+}
+
+func validateNumber(schema map[string]interface{}, n float64, fail func(string, ...interface{})) {
+	if min, ok := schema["minimum"].(float64); ok && n < min {
+		fail("must be at least %v", min)
+	}
+	if max, ok := schema["maximum"].(float64); ok && n > max {
+		fail("must be at most %v", max)
+	}
+	if min, ok := schema["exclusiveMinimum"].(float64); ok && n <= min {
+		fail("must be greater than %v", min)
+	}
+	if max, ok := schema["exclusiveMaximum"].(float64); ok && n >= max {
+		fail("must be less than %v", max)
+	}
+}
+
+func validateObject(schema map[string]interface{}, obj map[string]interface{}, pointer string, violations *[]SchemaViolation, fail func(string, ...interface{})) {
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				if _, present := obj[name]; !present {
+					*violations = append(*violations, SchemaViolation{
+						Pointer: pointer + "/" + escapePointer(name),
+						Message: "is required",
+					})
+				}
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		child := pointer + "/" + escapePointer(name)
+		if propertySchema, ok := properties[name].(map[string]interface{}); ok {
+			validateValue(propertySchema, obj[name], child, violations)
+			continue
+		}
+		if _, declared := properties[name]; declared {
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				*violations = append(*violations, SchemaViolation{Pointer: child, Message: "is not an allowed property"})
+			}
+		case map[string]interface{}:
+			validateValue(additional, obj[name], child, violations)
+		}
+	}
+}
+
+func validateArray(schema map[string]interface{}, arr []interface{}, pointer string, violations *[]SchemaViolation, fail func(string, ...interface{})) {
+	if min, ok := schema["minItems"].(float64); ok && float64(len(arr)) < min {
+		fail("must have at least %v items", min)
+	}
+	if max, ok := schema["maxItems"].(float64); ok && float64(len(arr)) > max {
+		fail("must have at most %v items", max)
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		for i, item := range arr {
+			validateValue(items, item, fmt.Sprintf("%s/%d", pointer, i), violations)
+		}
+	}
+}
+
+// schemaTypes returns the types a schema's type keyword allows
+func schemaTypes(t interface{}) []string {
+	switch t := t.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, name := range t {
+			if name, ok := name.(string); ok {
+				types = append(types, name)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// matchesAnyType reports whether value is one of the JSON Schema types
+func matchesAnyType(types []string, value interface{}) bool {
+	actual := jsonType(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType names the JSON Schema type of a decoded JSON value
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// containsValue reports whether values holds a value equal to v
+func containsValue(values []interface{}, v interface{}) bool {
+	for _, candidate := range values {
+		if jsonEqual(candidate, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonEqual compares decoded JSON values by their encoding
+func jsonEqual(a, b interface{}) bool {
+	return encodeValue(a) == encodeValue(b)
+}
+
+func encodeValue(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// escapePointer escapes a property name for use in a JSON pointer
+func escapePointer(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
+
+// invalidParamsError reports schema violations as an InvalidParams error
+func invalidParamsError(violations []SchemaViolation) *JSONRPCError {
+	messages := make([]string, len(violations))
+	for i, v := range violations {
+		pointer := v.Pointer
+		if pointer == "" {
+			pointer = "/"
+		}
+		messages[i] = pointer + ": " + v.Message
+	}
+	return &JSONRPCError{
+		Code:    InvalidParams,
+		Message: "Invalid params: " + strings.Join(messages, "; "),
+		Data:    map[string]interface{}{"violations": violations},
+	}
+}
@@ -0,0 +1,161 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+var testSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"node_id": map[string]interface{}{
+			"type":    "string",
+			"pattern": "^[a-z0-9-]+$",
+		},
+		"priority": map[string]interface{}{
+			"type": "string",
+			"enum": []string{"low", "normal", "high"},
+		},
+		"retries": map[string]interface{}{
+			"type":    "integer",
+			"minimum": 0,
+			"maximum": 5,
+		},
+		"tags": map[string]interface{}{
+			"type":     "array",
+			"maxItems": 2,
+			"items":    map[string]interface{}{"type": "string", "minLength": 1},
+		},
+		"limits": map[string]interface{}{
+			"type":     "object",
+			"required": []string{"cpu"},
+			"properties": map[string]interface{}{
+				"cpu": map[string]interface{}{"type": "number", "exclusiveMinimum": 0},
+			},
+			"additionalProperties": false,
+		},
+	},
+	"required": []string{"node_id", "payload"},
+}
+
+// decodeJSON decodes a JSON literal for use as a validated value
+func decodeJSON(t *testing.T, data string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		t.Fatalf("Invalid test JSON %s: %v", data, err)
+	}
+	return v
+}
+
+func TestValidateSchemaValid(t *testing.T) {
+	value := decodeJSON(t, `{"node_id":"worker-01","payload":"x","priority":"high","retries":3,
+		"tags":["a","b"],"limits":{"cpu":0.5}}`)
+	if violations := ValidateSchema(testSchema, value); len(violations) != 0 {
+		t.Errorf("Expected no violations, got %+v", violations)
+	}
+}
+
+func TestValidateSchemaViolations(t *testing.T) {
+	value := decodeJSON(t, `{"node_id":"Worker 01","priority":"urgent","retries":2.5,
+		"tags":["a","",3],"limits":{"cpu":0,"gpu":1}}`)
+
+	expected := map[string]string{
+		"/payload":    "is required",
+		"/node_id":    "must match pattern",
+		"/priority":   "must be one of",
+		"/retries":    "expected integer",
+		"/tags":       "at most 2 items",
+		"/tags/1":     "at least 1 characters",
+		"/tags/2":     "expected string",
+		"/limits/cpu": "greater than 0",
+		"/limits/gpu": "not an allowed property",
+	}
+
+	violations := ValidateSchema(testSchema, value)
+	found := map[string]string{}
+	for _, v := range violations {
+		found[v.Pointer] = v.Message
+	}
+	for pointer, message := range expected {
+		if !strings.Contains(found[pointer], message) {
+			t.Errorf("Expected violation at %s containing %q, got %q", pointer, message, found[pointer])
+		}
+	}
+	if len(violations) != len(expected) {
+		t.Errorf("Expected %d violations, got %+v", len(expected), violations)
+	}
+}
+
+func TestValidateSchemaTypes(t *testing.T) {
+	tests := []struct {
+		schema map[string]interface{}
+		value  string
+		valid  bool
+	}{
+		{map[string]interface{}{"type": "number"}, `3`, true},
+		{map[string]interface{}{"type": "integer"}, `3.5`, false},
+		{map[string]interface{}{"type": "boolean"}, `"true"`, false},
+		{map[string]interface{}{"type": []string{"string", "null"}}, `null`, true},
+		{map[string]interface{}{"type": "object"}, `[]`, false},
+		{map[string]interface{}{"const": "fixed"}, `"fixed"`, true},
+		{map[string]interface{}{"const": "fixed"}, `"other"`, false},
+		{map[string]interface{}{}, `{"anything":true}`, true},
+	}
+	for _, tt := range tests {
+		violations := ValidateSchema(tt.schema, decodeJSON(t, tt.value))
+		if (len(violations) == 0) != tt.valid {
+			t.Errorf("Schema %v with %s: expected valid=%v, got %+v", tt.schema, tt.value, tt.valid, violations)
+		}
+	}
+}
+
+func TestValidateSchemaEscapesPointers(t *testing.T) {
+	schema := map[string]interface{}{"type": "object", "required": []string{"a/b~c"}}
+	violations := ValidateSchema(schema, decodeJSON(t, `{}`))
+	if len(violations) != 1 || violations[0].Pointer != "/a~1b~0c" {
+		t.Errorf("Expected escaped pointer, got %+v", violations)
+	}
+}
+
+func TestToolsCallValidatesArguments(t *testing.T) {
+	server := NewServer("test-server", "1.0.0")
+	called := false
+	server.RegisterTool(Tool{Name: "submit", InputSchema: testSchema},
+		func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			called = true
+			return "ok", nil
+		})
+
+	resp := server.handleRequest(context.Background(), JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "tools/call",
+		Params:  ToolsCallRequest{Name: "submit", Arguments: map[string]interface{}{"payload": 42}},
+	})
+	if resp.Error == nil || resp.Error.Code != InvalidParams {
+		t.Fatalf("Expected InvalidParams, got %+v", resp)
+	}
+	if called {
+		t.Error("Handler should not run when validation fails")
+	}
+	if !strings.Contains(resp.Error.Message, "/node_id: is required") {
+		t.Errorf("Expected error message to list violations, got %q", resp.Error.Message)
+	}
+	data, _ := json.Marshal(resp.Error.Data)
+	if !strings.Contains(string(data), `"pointer":"/node_id"`) {
+		t.Errorf("Expected violations in error data, got %s", data)
+	}
+
+	resp = server.handleRequest(context.Background(), JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      2,
+		Method:  "tools/call",
+		Params:  ToolsCallRequest{Name: "submit", Arguments: map[string]interface{}{"node_id": "a", "payload": "x"}},
+	})
+	if resp.Error != nil || !called {
+		t.Errorf("Expected valid call to reach the handler, got %+v", resp.Error)
+	}
+}
@@ -1,14 +1,17 @@
 package mcp
 
 import (
-	"bufio"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"os"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Server represents an MCP server instance
@@ -16,39 +19,252 @@ type Server struct {
 	info         ServerInfo
 	capabilities ServerCapabilities
 	tools        map[string]Tool
+	toolSchemas  map[string]map[string]interface{}
 	resources    map[string]Resource
+	templates    map[string]*resourceTemplate
 	prompts      map[string]Prompt
+	completions  map[string]CompletionHandler
 	handlers     map[string]Handler
+	sessions     map[string]*session
 	mu           sync.RWMutex
 	initialized  bool
-	logger       *log.Logger
+
+	// logger and transportLog write server diagnostics; stderr receives
+	// messages at or above logLevel
+	logger       *Logger
+	transportLog *Logger
+	stderr       *log.Logger
+	logLevel     LoggingLevel
+
+	// slots bounds how many tool calls are handled concurrently
+	slots           chan struct{}
+	shutdownTimeout time.Duration
+	// pageSize is how many entries each list response holds
+	pageSize int
+}
+
+// Defaults for server options
+const (
+	DefaultMaxConcurrentRequests = 16
+	DefaultShutdownTimeout       = 10 * time.Second
+	DefaultPageSize              = 100
+)
+
+// Option configures optional server behavior
+type Option func(*Server)
+
+// WithMaxConcurrentRequests bounds how many tool calls run their handlers
+// at once; further calls wait for a free slot
+func WithMaxConcurrentRequests(n int) Option {
+	return func(s *Server) {
+		if n > 0 {
+			s.slots = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithShutdownTimeout sets how long Run waits for in-flight requests to
+// finish before cancelling them
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		s.shutdownTimeout = d
+	}
+}
+
+// WithLogLevel sets the minimum level of messages written to stderr. It
+// does not affect what clients receive, which they choose themselves.
+func WithLogLevel(level LoggingLevel) Option {
+	return func(s *Server) {
+		if _, known := logSeverity[level]; known {
+			s.logLevel = level
+		}
+	}
+}
+
+// WithPageSize sets how many entries tools/list, resources/list and
+// prompts/list return per page
+func WithPageSize(n int) Option {
+	return func(s *Server) {
+		if n > 0 {
+			s.pageSize = n
+		}
+	}
+}
+
+// session is the server-side state of one connected client
+type session struct {
+	id string
+	// send delivers a server-initiated message to the client
+	send func(data []byte) error
+
+	inflightMu sync.Mutex
+	inflight   map[string]*inflightRequest
+
+	subscriptionsMu sync.Mutex
+	subscriptions   map[string]bool
+
+	// initialized is set once the client sends notifications/initialized
+	initialized atomic.Bool
+
+	// The protocol revision, client identity and capabilities agreed by
+	// initialize
+	negotiatedMu       sync.RWMutex
+	protocolVersion    string
+	clientInfo         ClientInfo
+	clientCapabilities ClientCapabilities
+
+	// Server-initiated requests awaiting a client response
+	pendingMu     sync.Mutex
+	pending       map[string]chan clientResponse
+	nextRequestID atomic.Int64
+
+	// logLevel is the minimum level of log messages the client is sent,
+	// or empty until it sets one
+	logLevelMu sync.Mutex
+	logLevel   LoggingLevel
+}
+
+// inflightRequest is a request the client may still cancel
+type inflightRequest struct {
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+// requestKey identifies a JSON-RPC request ID within a session, keeping
+// numeric and string IDs distinct
+func requestKey(id interface{}) string {
+	data, _ := json.Marshal(id)
+	return string(data)
+}
+
+// track registers a request so it can be cancelled. The returned function
+// must be called when the request finishes; it reports whether the client
+// cancelled the request.
+func (sess *session) track(ctx context.Context, id interface{}) (context.Context, func() bool) {
+	ctx, cancel := context.WithCancel(ctx)
+	key := requestKey(id)
+	req := &inflightRequest{cancel: cancel}
+
+	sess.inflightMu.Lock()
+	if sess.inflight == nil {
+		sess.inflight = make(map[string]*inflightRequest)
+	}
+	sess.inflight[key] = req
+	sess.inflightMu.Unlock()
+
+	return ctx, func() bool {
+		sess.inflightMu.Lock()
+		defer sess.inflightMu.Unlock()
+		if sess.inflight[key] == req {
+			delete(sess.inflight, key)
+		}
+		cancel()
+		return req.cancelled
+	}
+}
+
+// notify sends a server-initiated notification to the client
+func (sess *session) notify(method string, params interface{}) error {
+	return sess.notifyFor(context.Background(), method, params)
+}
+
+// notifyFor sends a notification belonging to the request being handled
+// with ctx
+func (sess *session) notifyFor(ctx context.Context, method string, params interface{}) error {
+	data, err := json.Marshal(JSONRPCNotification{JSONRPC: "2.0", Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	return sess.sendFor(ctx, data)
+}
+
+// sendFor delivers a server-initiated message belonging to the request
+// being handled with ctx, on the request's own stream when the transport
+// opened one
+func (sess *session) sendFor(ctx context.Context, data []byte) error {
+	if send, ok := ctx.Value(requestStreamContextKey).(func([]byte) error); ok {
+		return send(data)
+	}
+	return sess.send(data)
+}
+
+// cancelRequest cancels an in-flight request, reporting whether it was found
+func (sess *session) cancelRequest(id interface{}) bool {
+	sess.inflightMu.Lock()
+	defer sess.inflightMu.Unlock()
+	req, exists := sess.inflight[requestKey(id)]
+	if !exists {
+		return false
+	}
+	req.cancelled = true
+	req.cancel()
+	return true
+}
+
+type contextKey int
+
+const (
+	sessionContextKey contextKey = iota
+	requestStreamContextKey
+)
+
+// withSession attaches the client session to a request context
+func withSession(ctx context.Context, sess *session) context.Context {
+	return context.WithValue(ctx, sessionContextKey, sess)
+}
+
+// withRequestStream routes the server-initiated messages of a request,
+// such as its progress, to send rather than the session's stream
+func withRequestStream(ctx context.Context, send func([]byte) error) context.Context {
+	return context.WithValue(ctx, requestStreamContextKey, send)
+}
+
+// sessionFromContext returns the session a request belongs to, if any
+func sessionFromContext(ctx context.Context) *session {
+	sess, _ := ctx.Value(sessionContextKey).(*session)
+	return sess
 }
 
 // Handler represents a method handler function
 type Handler func(ctx context.Context, params json.RawMessage) (interface{}, error)
 
 // NewServer creates a new MCP server instance
-func NewServer(name, version string) *Server {
+func NewServer(name, version string, opts ...Option) *Server {
 	server := &Server{
 		info: ServerInfo{
 			Name:    name,
 			Version: version,
 		},
 		capabilities: ServerCapabilities{
-			Logging: &LoggingCapability{},
-			Tools:   &ToolsCapability{ListChanged: false},
+			Logging:     &LoggingCapability{},
+			Completions: &CompletionsCapability{},
+			Tools:       &ToolsCapability{ListChanged: true},
 			Resources: &ResourcesCapability{
-				Subscribe:   false,
-				ListChanged: false,
+				Subscribe:   true,
+				ListChanged: true,
 			},
-			Prompts: &PromptsCapability{ListChanged: false},
+			Prompts: &PromptsCapability{ListChanged: true},
 		},
-		tools:     make(map[string]Tool),
-		resources: make(map[string]Resource),
-		prompts:   make(map[string]Prompt),
-		handlers:  make(map[string]Handler),
-		logger:    log.New(os.Stderr, "[MCP Server] ", log.LstdFlags),
+		tools:       make(map[string]Tool),
+		toolSchemas: make(map[string]map[string]interface{}),
+		resources:   make(map[string]Resource),
+		templates:   make(map[string]*resourceTemplate),
+		prompts:     make(map[string]Prompt),
+		completions: make(map[string]CompletionHandler),
+		handlers:    make(map[string]Handler),
+		sessions:    make(map[string]*session),
+		stderr:      log.New(os.Stderr, "", log.LstdFlags),
+		logLevel:    LogLevelInfo,
+
+		slots:           make(chan struct{}, DefaultMaxConcurrentRequests),
+		shutdownTimeout: DefaultShutdownTimeout,
+		pageSize:        DefaultPageSize,
 	}
+	for _, opt := range opts {
+		opt(server)
+	}
+	server.logger = server.Logger("mcp.server")
+	server.transportLog = server.Logger("mcp.transport")
 
 	// Register core MCP handlers
 	server.registerCoreHandlers()
@@ -58,125 +274,341 @@ func NewServer(name, version string) *Server {
 // registerCoreHandlers registers the standard MCP protocol handlers
 func (s *Server) registerCoreHandlers() {
 	s.handlers["initialize"] = s.handleInitialize
+	s.handlers["notifications/initialized"] = s.handleInitialized
+	// Clients predating the spec's notification name send "initialized"
 	s.handlers["initialized"] = s.handleInitialized
+	s.handlers["ping"] = s.handlePing
+	s.handlers["notifications/cancelled"] = s.handleCancelled
+	s.handlers["logging/setLevel"] = s.handleLoggingSetLevel
 	s.handlers["tools/list"] = s.handleToolsList
 	s.handlers["tools/call"] = s.handleToolsCall
 	s.handlers["resources/list"] = s.handleResourcesList
 	s.handlers["resources/read"] = s.handleResourcesRead
+	s.handlers["resources/templates/list"] = s.handleResourceTemplatesList
+	s.handlers["resources/subscribe"] = s.handleResourcesSubscribe
+	s.handlers["resources/unsubscribe"] = s.handleResourcesUnsubscribe
 	s.handlers["prompts/list"] = s.handlePromptsList
 	s.handlers["prompts/get"] = s.handlePromptsGet
+	s.handlers["completion/complete"] = s.handleComplete
 }
 
-// RegisterTool registers a new tool with the server
+// RegisterTool registers a new tool with the server, replacing any tool
+// of the same name. Initialized clients are told the tool list changed.
 func (s *Server) RegisterTool(tool Tool, handler Handler) {
+	// Arguments are validated against the input schema before dispatch
+	schema, err := normalizeSchema(tool.InputSchema)
+	if err != nil {
+		s.logger.Warningf("Tool %s has an invalid input schema, arguments will not be validated: %v", tool.Name, err)
+	}
+
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	s.tools[tool.Name] = tool
+	s.toolSchemas[tool.Name] = schema
 	s.handlers["tool_"+tool.Name] = handler
+	s.mu.Unlock()
+	s.notifyListChanged("notifications/tools/list_changed")
+}
+
+// UnregisterTool removes a tool, reporting whether it was registered
+func (s *Server) UnregisterTool(name string) bool {
+	s.mu.Lock()
+	_, exists := s.tools[name]
+	delete(s.tools, name)
+	delete(s.toolSchemas, name)
+	delete(s.handlers, "tool_"+name)
+	s.mu.Unlock()
+	if exists {
+		s.notifyListChanged("notifications/tools/list_changed")
+	}
+	return exists
 }
 
-// RegisterResource registers a new resource with the server
+// RegisterResource registers a new resource with the server, replacing any
+// resource with the same URI. Initialized clients are told the resource
+// list changed.
 func (s *Server) RegisterResource(resource Resource, handler Handler) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	s.resources[resource.URI] = resource
 	s.handlers["resource_"+resource.URI] = handler
+	s.mu.Unlock()
+	s.notifyListChanged("notifications/resources/list_changed")
+}
+
+// UnregisterResource removes a resource, reporting whether it was registered
+func (s *Server) UnregisterResource(uri string) bool {
+	s.mu.Lock()
+	_, exists := s.resources[uri]
+	delete(s.resources, uri)
+	delete(s.handlers, "resource_"+uri)
+	s.mu.Unlock()
+	if exists {
+		s.notifyListChanged("notifications/resources/list_changed")
+	}
+	return exists
 }
 
-// RegisterPrompt registers a new prompt with the server
+// RegisterPrompt registers a new prompt with the server, replacing any
+// prompt of the same name. Initialized clients are told the prompt list
+// changed.
 func (s *Server) RegisterPrompt(prompt Prompt, handler Handler) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	s.prompts[prompt.Name] = prompt
 	s.handlers["prompt_"+prompt.Name] = handler
+	s.mu.Unlock()
+	s.notifyListChanged("notifications/prompts/list_changed")
 }
 
-// Run starts the MCP server, reading from stdin and writing to stdout
-func (s *Server) Run(ctx context.Context) error {
-	s.logger.Printf("Starting MCP server %s v%s", s.info.Name, s.info.Version)
-	
-	reader := bufio.NewReader(os.Stdin)
-	writer := bufio.NewWriter(os.Stdout)
-	defer writer.Flush()
+// UnregisterPrompt removes a prompt, reporting whether it was registered
+func (s *Server) UnregisterPrompt(name string) bool {
+	s.mu.Lock()
+	_, exists := s.prompts[name]
+	delete(s.prompts, name)
+	delete(s.handlers, "prompt_"+name)
+	s.mu.Unlock()
+	if exists {
+		s.notifyListChanged("notifications/prompts/list_changed")
+	}
+	return exists
+}
+
+// notifyListChanged sends a list_changed notification to every client
+// that has finished initializing. Clients still initializing fetch the
+// current lists afterwards anyway.
+func (s *Server) notifyListChanged(method string) {
+	s.mu.RLock()
+	var recipients []*session
+	for _, sess := range s.sessions {
+		if sess.initialized.Load() {
+			recipients = append(recipients, sess)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, sess := range recipients {
+		if err := sess.notify(method, nil); err != nil {
+			s.transportLog.Errorf("Error sending %s to session %s: %v", method, sess.id, err)
+		}
+	}
+}
+
+// Run serves a single client over transport until the client disconnects
+// or ctx is cancelled. Requests are handled concurrently; on shutdown Run
+// stops reading and waits up to the shutdown timeout for in-flight requests
+// before cancelling them. The transport is closed when Run returns.
+func (s *Server) Run(ctx context.Context, transport Transport) error {
+	s.logger.Infof("Starting MCP server %s v%s", s.info.Name, s.info.Version)
+	defer transport.Close()
+
+	// Handlers outlive ctx so in-flight requests can finish during shutdown
+	handlerCtx, cancelHandlers := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelHandlers()
+
+	id, err := newSessionID()
+	if err != nil {
+		return fmt.Errorf("creating session: %w", err)
+	}
+	sess := &session{
+		id: id,
+		send: func(data []byte) error {
+			return transport.WriteMessage(handlerCtx, data)
+		},
+	}
+	s.addSession(sess)
+	defer s.removeSession(sess.id)
+	handlerCtx = withSession(handlerCtx, sess)
+
+	// Read in the background so shutdown is not held up by a blocked read
+	messages := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			data, err := transport.ReadMessage(handlerCtx)
+			if err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case messages <- data:
+			case <-handlerCtx.Done():
+				return
+			}
+		}
+	}()
 
+	var inflight sync.WaitGroup
 	for {
 		select {
 		case <-ctx.Done():
-			s.logger.Println("Server shutting down...")
+			s.logger.Infof("Server shutting down...")
+			s.drain(&inflight, cancelHandlers)
 			return ctx.Err()
-		default:
-		}
 
-		// Read message line
-		line, err := reader.ReadBytes('\n')
-		if err != nil {
-			if err == io.EOF {
-				s.logger.Println("Client disconnected")
+		case err := <-readErr:
+			s.drain(&inflight, cancelHandlers)
+			if isClosedError(err) {
+				s.transportLog.Infof("Client disconnected")
 				return nil
 			}
-			s.logger.Printf("Error reading input: %v", err)
-			continue
+			return fmt.Errorf("reading message: %w", err)
+
+		case data := <-messages:
+			// Notifications are handled in order; requests run concurrently
+			if isNotification(data) {
+				s.processMessage(handlerCtx, data)
+				continue
+			}
+			inflight.Add(1)
+			go func() {
+				defer inflight.Done()
+				s.processMessage(handlerCtx, data)
+			}()
 		}
+	}
+}
 
-		// Process message in goroutine
-		go s.processMessage(ctx, line, writer)
+// drain waits for in-flight requests, cancelling them if they outlast the
+// shutdown timeout
+func (s *Server) drain(inflight *sync.WaitGroup, cancel context.CancelFunc) {
+	done := make(chan struct{})
+	go func() {
+		inflight.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(s.shutdownTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		s.logger.Warningf("In-flight requests still running after %v, cancelling them", s.shutdownTimeout)
+		cancel()
 	}
 }
 
-// processMessage processes a single JSON-RPC message
-func (s *Server) processMessage(ctx context.Context, data []byte, writer *bufio.Writer) {
+// isNotification reports whether data is a well-formed message without an ID
+func isNotification(data []byte) bool {
+	var probe struct {
+		ID     interface{} `json:"id"`
+		Method string      `json:"method"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return false
+	}
+	return probe.ID == nil && probe.Method != ""
+}
+
+// processMessage processes a single JSON-RPC message and sends any
+// response to the session in ctx
+func (s *Server) processMessage(ctx context.Context, data []byte) {
+	response := s.handleMessage(ctx, data)
+	if response == nil {
+		return
+	}
+	if err := sessionFromContext(ctx).send(response); err != nil {
+		s.transportLog.Errorf("Error writing response: %v", err)
+	}
+}
+
+// handleMessage processes one encoded JSON-RPC message independently of the
+// transport it arrived on. It returns the encoded response, or nil when no
+// response is due.
+func (s *Server) handleMessage(ctx context.Context, data []byte) []byte {
+	sess := sessionFromContext(ctx)
+	if isBatch(data) {
+		// Batching was removed from the protocol in 2025-06-18
+		if sess != nil {
+			if version, _ := sess.negotiated(); version >= ProtocolVersion20250618 {
+				return s.encodeResponse(errorResponse(nil, InvalidRequest, "Invalid Request", "batches are not supported in protocol version "+version))
+			}
+		}
+		return s.handleBatch(ctx, data)
+	}
+
 	var req JSONRPCRequest
 	if err := json.Unmarshal(data, &req); err != nil {
-		s.sendError(writer, nil, ParseError, "Parse error", err.Error())
-		return
+		return s.encodeResponse(errorResponse(nil, ParseError, "Parse error", err.Error()))
 	}
 
 	// Handle notifications (no response expected)
 	if req.ID == nil {
 		s.handleNotification(ctx, req)
-		return
+		return nil
 	}
 
-	// Handle regular requests
-	s.handleRequest(ctx, req, writer)
+	// Responses answer requests the server sent to the client
+	if req.Method == "" {
+		s.handleResponse(ctx, data)
+		return nil
+	}
+
+	// Handle regular requests, tracking them so the client can cancel them
+	if sess == nil || req.Method == "initialize" {
+		return s.encodeResponse(s.handleRequest(ctx, req))
+	}
+	if version, _ := sess.negotiated(); version == "" && req.Method != "ping" {
+		return s.encodeResponse(errorResponse(req.ID, InvalidRequest, "Server not initialized", req.Method))
+	}
+	ctx, finish := sess.track(ctx, req.ID)
+	response := s.handleRequest(ctx, req)
+	if cancelled := finish(); cancelled {
+		// The client no longer expects a response
+		return nil
+	}
+	return s.encodeResponse(response)
 }
 
-// handleRequest processes a JSON-RPC request and sends a response
-func (s *Server) handleRequest(ctx context.Context, req JSONRPCRequest, writer *bufio.Writer) {
+// handleRequest processes a JSON-RPC request and returns its response
+func (s *Server) handleRequest(ctx context.Context, req JSONRPCRequest) JSONRPCResponse {
+	s.mu.RLock()
 	handler, exists := s.handlers[req.Method]
+	s.mu.RUnlock()
 	if !exists {
-		s.sendError(writer, req.ID, MethodNotFound, "Method not found", req.Method)
-		return
+		return errorResponse(req.ID, MethodNotFound, "Method not found", req.Method)
+	}
+
+	// Tool calls may run for minutes, so they wait for a free slot; other
+	// methods are quick and must keep working while the slots are taken
+	if req.Method == "tools/call" {
+		select {
+		case s.slots <- struct{}{}:
+			defer func() { <-s.slots }()
+		case <-ctx.Done():
+			return errorResponse(req.ID, InternalError, "Request cancelled", ctx.Err().Error())
+		}
 	}
 
 	var params json.RawMessage
 	if req.Params != nil {
 		paramBytes, err := json.Marshal(req.Params)
 		if err != nil {
-			s.sendError(writer, req.ID, InvalidParams, "Invalid params", err.Error())
-			return
+			return errorResponse(req.ID, InvalidParams, "Invalid params", err.Error())
 		}
 		params = paramBytes
+		ctx = withProgressToken(ctx, params)
 	}
 
 	result, err := handler(ctx, params)
 	if err != nil {
-		s.sendError(writer, req.ID, InternalError, "Internal error", err.Error())
-		return
+		var rpcErr *JSONRPCError
+		if errors.As(err, &rpcErr) {
+			return JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: rpcErr}
+		}
+		return errorResponse(req.ID, InternalError, "Internal error", err.Error())
 	}
 
-	response := JSONRPCResponse{
+	return JSONRPCResponse{
 		JSONRPC: "2.0",
 		ID:      req.ID,
 		Result:  result,
 	}
-
-	s.sendResponse(writer, response)
 }
 
 // handleNotification processes a JSON-RPC notification (no response sent)
 func (s *Server) handleNotification(ctx context.Context, req JSONRPCRequest) {
-	if handler, exists := s.handlers[req.Method]; exists {
+	s.mu.RLock()
+	handler, exists := s.handlers[req.Method]
+	s.mu.RUnlock()
+	if exists {
 		var params json.RawMessage
 		if req.Params != nil {
 			paramBytes, _ := json.Marshal(req.Params)
@@ -193,12 +625,24 @@ func (s *Server) handleInitialize(ctx context.Context, params json.RawMessage) (
 	if err := json.Unmarshal(params, &req); err != nil {
 		return nil, fmt.Errorf("invalid initialize request: %w", err)
 	}
+	if req.ProtocolVersion == "" {
+		return nil, &JSONRPCError{Code: InvalidParams, Message: "Invalid params: protocolVersion is required"}
+	}
 
-	s.logger.Printf("Initialize request from %s v%s", req.ClientInfo.Name, req.ClientInfo.Version)
+	version := negotiateVersion(req.ProtocolVersion)
+	if sess := sessionFromContext(ctx); sess != nil {
+		sess.setNegotiated(version, req.ClientInfo, req.Capabilities)
+	}
+	s.logger.Infof("Initialize request from %s v%s, protocol version %s (requested %s)",
+		req.ClientInfo.Name, req.ClientInfo.Version, version, req.ProtocolVersion)
 
+	capabilities := s.capabilities
+	if version < ProtocolVersion20250326 {
+		capabilities.Completions = nil
+	}
 	response := InitializeResponse{
-		ProtocolVersion: MCPVersion,
-		Capabilities:    s.capabilities,
+		ProtocolVersion: version,
+		Capabilities:    capabilities,
 		ServerInfo:      s.info,
 	}
 
@@ -209,21 +653,53 @@ func (s *Server) handleInitialized(ctx context.Context, params json.RawMessage)
 	s.mu.Lock()
 	s.initialized = true
 	s.mu.Unlock()
+	if sess := sessionFromContext(ctx); sess != nil {
+		sess.initialized.Store(true)
+	}
 
-	s.logger.Println("Server initialized successfully")
+	s.logger.Infof("Server initialized successfully")
+	return nil, nil
+}
+
+func (s *Server) handlePing(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	return struct{}{}, nil
+}
+
+func (s *Server) handleCancelled(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var notification CancelledNotification
+	if err := json.Unmarshal(params, &notification); err != nil {
+		return nil, fmt.Errorf("invalid cancelled notification: %w", err)
+	}
+
+	sess := sessionFromContext(ctx)
+	if sess == nil || !sess.cancelRequest(notification.RequestID) {
+		// The request already finished or never existed
+		return nil, nil
+	}
+
+	s.logger.Infof("Request %v cancelled by client: %s", notification.RequestID, notification.Reason)
 	return nil, nil
 }
 
 func (s *Server) handleToolsList(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req ToolsListRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	tools := make([]Tool, 0, len(s.tools))
-	for _, tool := range s.tools {
-		tools = append(tools, tool)
+	page, nextCursor, err := paginate(mapKeys(s.tools), req.Cursor, s.pageSize)
+	if err != nil {
+		return nil, err
+	}
+	tools := make([]Tool, 0, len(page))
+	for _, key := range page {
+		tools = append(tools, toolForVersion(ctx, s.tools[key]))
 	}
 
-	return ToolsListResponse{Tools: tools}, nil
+	return ToolsListResponse{Tools: tools, NextCursor: nextCursor}, nil
 }
 
 func (s *Server) handleToolsCall(ctx context.Context, params json.RawMessage) (interface{}, error) {
@@ -233,44 +709,62 @@ func (s *Server) handleToolsCall(ctx context.Context, params json.RawMessage) (i
 	}
 
 	// Find the tool handler
+	s.mu.RLock()
 	handler, exists := s.handlers["tool_"+req.Name]
+	schema := s.toolSchemas[req.Name]
+	s.mu.RUnlock()
 	if !exists {
 		return nil, fmt.Errorf("tool not found: %s", req.Name)
 	}
 
+	if schema != nil {
+		var args interface{} = req.Arguments
+		if req.Arguments == nil {
+			args = map[string]interface{}{}
+		}
+		var violations []SchemaViolation
+		validateValue(schema, args, "", &violations)
+		if len(violations) > 0 {
+			return nil, invalidParamsError(violations)
+		}
+	}
+
 	// Marshal arguments back to JSON for the handler
 	argsBytes, _ := json.Marshal(req.Arguments)
-	
+
 	result, err := handler(ctx, argsBytes)
 	if err != nil {
-		return ToolsCallResponse{
-			Content: []Content{{
-				Type: "text",
-				Text: fmt.Sprintf("Error executing tool %s: %v", req.Name, err),
-			}},
-			IsError: true,
-		}, nil
+		return toolError(req.Name, err), nil
 	}
-
-	// Convert result to content
-	content := []Content{{
-		Type: "text",
-		Text: fmt.Sprintf("%v", result),
-	}}
-
-	return ToolsCallResponse{Content: content}, nil
+	response, err := toolResult(result)
+	if err != nil {
+		return toolError(req.Name, err), nil
+	}
+	if !versionAtLeast(ctx, ProtocolVersion20250618) {
+		response.StructuredContent = nil
+	}
+	return response, nil
 }
 
 func (s *Server) handleResourcesList(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req ResourcesListRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	resources := make([]Resource, 0, len(s.resources))
-	for _, resource := range s.resources {
-		resources = append(resources, resource)
+	page, nextCursor, err := paginate(mapKeys(s.resources), req.Cursor, s.pageSize)
+	if err != nil {
+		return nil, err
+	}
+	resources := make([]Resource, 0, len(page))
+	for _, key := range page {
+		resources = append(resources, s.resources[key])
 	}
 
-	return ResourcesListResponse{Resources: resources}, nil
+	return ResourcesListResponse{Resources: resources, NextCursor: nextCursor}, nil
 }
 
 func (s *Server) handleResourcesRead(ctx context.Context, params json.RawMessage) (interface{}, error) {
@@ -278,10 +772,31 @@ func (s *Server) handleResourcesRead(ctx context.Context, params json.RawMessage
 	if err := json.Unmarshal(params, &req); err != nil {
 		return nil, fmt.Errorf("invalid resources/read request: %w", err)
 	}
+	return s.readResource(ctx, req.URI, params)
+}
 
-	handler, exists := s.handlers["resource_"+req.URI]
+// ReadResource reads a registered resource, or one generated by a
+// template, as resources/read would
+func (s *Server) ReadResource(ctx context.Context, uri string) (interface{}, error) {
+	params, err := json.Marshal(ResourcesReadRequest{URI: uri})
+	if err != nil {
+		return nil, err
+	}
+	return s.readResource(ctx, uri, params)
+}
+
+// readResource dispatches a read to the resource's handler or template
+func (s *Server) readResource(ctx context.Context, uri string, params json.RawMessage) (interface{}, error) {
+	s.mu.RLock()
+	handler, exists := s.handlers["resource_"+uri]
+	s.mu.RUnlock()
 	if !exists {
-		return nil, fmt.Errorf("resource not found: %s", req.URI)
+		// Fall back to the templates, which may generate the resource
+		template, vars := s.matchResourceTemplate(uri)
+		if template == nil {
+			return nil, fmt.Errorf("resource not found: %s", uri)
+		}
+		return template.handler(ctx, uri, vars)
 	}
 
 	result, err := handler(ctx, params)
@@ -293,15 +808,24 @@ func (s *Server) handleResourcesRead(ctx context.Context, params json.RawMessage
 }
 
 func (s *Server) handlePromptsList(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req PromptsListRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	prompts := make([]Prompt, 0, len(s.prompts))
-	for _, prompt := range s.prompts {
-		prompts = append(prompts, prompt)
+	page, nextCursor, err := paginate(mapKeys(s.prompts), req.Cursor, s.pageSize)
+	if err != nil {
+		return nil, err
+	}
+	prompts := make([]Prompt, 0, len(page))
+	for _, key := range page {
+		prompts = append(prompts, s.prompts[key])
 	}
 
-	return PromptsListResponse{Prompts: prompts}, nil
+	return PromptsListResponse{Prompts: prompts, NextCursor: nextCursor}, nil
 }
 
 func (s *Server) handlePromptsGet(ctx context.Context, params json.RawMessage) (interface{}, error) {
@@ -310,7 +834,9 @@ func (s *Server) handlePromptsGet(ctx context.Context, params json.RawMessage) (
 		return nil, fmt.Errorf("invalid prompts/get request: %w", err)
 	}
 
+	s.mu.RLock()
 	handler, exists := s.handlers["prompt_"+req.Name]
+	s.mu.RUnlock()
 	if !exists {
 		return nil, fmt.Errorf("prompt not found: %s", req.Name)
 	}
@@ -325,20 +851,17 @@ func (s *Server) handlePromptsGet(ctx context.Context, params json.RawMessage) (
 
 // Utility methods
 
-func (s *Server) sendResponse(writer *bufio.Writer, response JSONRPCResponse) {
+func (s *Server) encodeResponse(response JSONRPCResponse) []byte {
 	data, err := json.Marshal(response)
 	if err != nil {
-		s.logger.Printf("Error marshaling response: %v", err)
-		return
+		s.logger.Errorf("Error marshaling response: %v", err)
+		data, _ = json.Marshal(errorResponse(response.ID, InternalError, "Internal error", err.Error()))
 	}
-
-	writer.Write(data)
-	writer.WriteByte('\n')
-	writer.Flush()
+	return data
 }
 
-func (s *Server) sendError(writer *bufio.Writer, id interface{}, code int, message, data string) {
-	response := JSONRPCResponse{
+func errorResponse(id interface{}, code int, message, data string) JSONRPCResponse {
+	return JSONRPCResponse{
 		JSONRPC: "2.0",
 		ID:      id,
 		Error: &JSONRPCError{
@@ -347,8 +870,29 @@ func (s *Server) sendError(writer *bufio.Writer, id interface{}, code int, messa
 			Data:    data,
 		},
 	}
+}
+
+// newSessionID returns a random session identifier
+func newSessionID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// addSession registers a connected client
+func (s *Server) addSession(sess *session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[sess.id] = sess
+}
 
-	s.sendResponse(writer, response)
+// removeSession forgets a disconnected client
+func (s *Server) removeSession(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
 }
 
 // IsInitialized returns whether the server has been initialized
@@ -356,4 +900,4 @@ func (s *Server) IsInitialized() bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	return s.initialized
-}
\ No newline at end of file
+}
@@ -1,10 +1,16 @@
 package mcp
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestNewServer(t *testing.T) {
@@ -252,8 +258,13 @@ func TestHandleToolsCall(t *testing.T) {
 		t.Errorf("Expected 1 content item, got %d", len(response.Content))
 	}
 	
-	if !strings.Contains(response.Content[0].Text, "result:success") {
-		t.Errorf("Expected content to contain 'result:success', got '%s'", response.Content[0].Text)
+	if response.Content[0].Text != `{"result":"success"}` {
+		t.Errorf("Expected JSON text content, got '%s'", response.Content[0].Text)
+	}
+
+	structured, _ := json.Marshal(response.StructuredContent)
+	if string(structured) != `{"result":"success"}` {
+		t.Errorf("Expected structured content, got %s", structured)
 	}
 }
 
@@ -408,4 +419,264 @@ func TestErrorCodes(t *testing.T) {
 			t.Errorf("Expected error code %s to be %d, got %d", name, expected, actual)
 		}
 	}
-}
\ No newline at end of file
+}
+// streamPipe runs server over a StreamTransport backed by OS-style pipes,
+// returning the client's writer and a scanner over the server's output
+func streamPipe(t *testing.T, server *Server, ctx context.Context) (io.WriteCloser, *bufio.Scanner, <-chan error) {
+	t.Helper()
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Run(ctx, NewStreamTransport(inReader, outWriter))
+		outWriter.Close()
+	}()
+	t.Cleanup(func() {
+		inWriter.Close()
+		outReader.Close()
+	})
+	return inWriter, bufio.NewScanner(outReader), done
+}
+
+func TestConcurrentToolCalls(t *testing.T) {
+	const requests = 300
+	server := NewServer("test-server", "1.0.0", WithMaxConcurrentRequests(8))
+	server.RegisterTool(Tool{Name: "echo", InputSchema: map[string]interface{}{"type": "object"}},
+		func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			var args struct {
+				N int `json:"n"`
+			}
+			json.Unmarshal(params, &args)
+			return strings.Repeat("x", 512) + fmt.Sprint(args.N), nil
+		})
+
+	input, output, done := streamPipe(t, server, context.Background())
+
+	data, _ := json.Marshal(JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      "init",
+		Method:  "initialize",
+		Params:  InitializeRequest{ProtocolVersion: MCPVersion},
+	})
+	input.Write(append(data, '\n'))
+	if !output.Scan() {
+		t.Fatal("Expected an initialize response")
+	}
+
+	go func() {
+		for i := 0; i < requests; i++ {
+			data, _ := json.Marshal(JSONRPCRequest{
+				JSONRPC: "2.0",
+				ID:      i,
+				Method:  "tools/call",
+				Params:  ToolsCallRequest{Name: "echo", Arguments: map[string]interface{}{"n": i}},
+			})
+			input.Write(append(data, '\n'))
+		}
+		input.Close()
+	}()
+
+	seen := map[int]bool{}
+	for output.Scan() {
+		var resp struct {
+			ID     int               `json:"id"`
+			Result ToolsCallResponse `json:"result"`
+		}
+		if err := json.Unmarshal(output.Bytes(), &resp); err != nil {
+			t.Fatalf("Interleaved or corrupt response line %q: %v", output.Text(), err)
+		}
+		if !strings.HasSuffix(resp.Result.Content[0].Text, fmt.Sprint(resp.ID)) {
+			t.Errorf("Response %d has wrong content", resp.ID)
+		}
+		seen[resp.ID] = true
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if len(seen) != requests {
+		t.Errorf("Expected %d responses, got %d", requests, len(seen))
+	}
+}
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	const limit = 3
+	server := NewServer("test-server", "1.0.0", WithMaxConcurrentRequests(limit))
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	server.RegisterTool(Tool{Name: "slow", InputSchema: map[string]interface{}{"type": "object"}},
+		func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			mu.Lock()
+			running++
+			peak = max(peak, running)
+			mu.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return "done", nil
+		})
+
+	client := startTestServer(t, server)
+	client.initialize()
+	for i := 0; i < 12; i++ {
+		client.send(JSONRPCRequest{JSONRPC: "2.0", ID: i, Method: "tools/call", Params: ToolsCallRequest{Name: "slow"}})
+	}
+	for i := 0; i < 12; i++ {
+		client.read()
+	}
+
+	if peak > limit {
+		t.Errorf("Expected at most %d concurrent handlers, saw %d", limit, peak)
+	}
+}
+
+func TestBusyToolsDoNotBlockOtherRequests(t *testing.T) {
+	server := NewServer("test-server", "1.0.0", WithMaxConcurrentRequests(1))
+	release := make(chan struct{})
+	server.RegisterTool(Tool{Name: "block", InputSchema: map[string]interface{}{"type": "object"}},
+		func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			<-release
+			return "done", nil
+		})
+
+	client := startTestServer(t, server)
+	client.initialize()
+	client.send(JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "tools/call", Params: ToolsCallRequest{Name: "block"}})
+
+	// The only slot is held, yet ping and listings are answered
+	for i, method := range []string{"ping", "tools/list", "resources/list"} {
+		client.send(JSONRPCRequest{JSONRPC: "2.0", ID: 10 + i, Method: method})
+		if msg := client.read(); msg.Error != nil {
+			t.Errorf("%s failed while a tool was running: %v", method, msg.Error)
+		}
+	}
+
+	close(release)
+	if msg := client.read(); msg.Error != nil {
+		t.Errorf("Tool call failed: %v", msg.Error)
+	}
+}
+
+func TestRunDrainsInFlightRequestsOnEOF(t *testing.T) {
+	server := NewServer("test-server", "1.0.0")
+	server.RegisterTool(Tool{Name: "slow", InputSchema: map[string]interface{}{"type": "object"}},
+		func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			time.Sleep(100 * time.Millisecond)
+			return "finished", nil
+		})
+
+	input, output, done := streamPipe(t, server, context.Background())
+	data, _ := json.Marshal(JSONRPCRequest{JSONRPC: "2.0", ID: 0, Method: "initialize", Params: InitializeRequest{ProtocolVersion: MCPVersion}})
+	input.Write(append(data, '\n'))
+	if !output.Scan() {
+		t.Fatal("Expected an initialize response")
+	}
+	data, _ = json.Marshal(JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "tools/call", Params: ToolsCallRequest{Name: "slow"}})
+	input.Write(append(data, '\n'))
+	input.Close()
+
+	if !output.Scan() {
+		t.Fatal("Expected the in-flight response to be written after EOF")
+	}
+	if !strings.Contains(output.Text(), "finished") {
+		t.Errorf("Unexpected response: %s", output.Text())
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Expected nil error on EOF, got %v", err)
+	}
+}
+
+func TestRunCancelsRequestsAfterShutdownTimeout(t *testing.T) {
+	server := NewServer("test-server", "1.0.0", WithShutdownTimeout(50*time.Millisecond))
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	server.RegisterTool(Tool{Name: "block", InputSchema: map[string]interface{}{"type": "object"}},
+		func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			close(started)
+			<-ctx.Done()
+			close(cancelled)
+			return nil, ctx.Err()
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client, serverTransport := NewPipeTransport()
+	defer client.Close()
+	done := make(chan error, 1)
+	go func() { done <- server.Run(ctx, serverTransport) }()
+	(&testClient{t: t, transport: client}).initialize()
+
+	data, _ := json.Marshal(JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "tools/call", Params: ToolsCallRequest{Name: "block"}})
+	client.WriteMessage(context.Background(), data)
+	<-started
+
+	// The handler keeps running through the drain period, then is cancelled
+	cancel()
+	select {
+	case <-cancelled:
+		t.Fatal("Handler was cancelled before the shutdown timeout")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the shutdown timeout")
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Handler context was not cancelled")
+	}
+}
+
+func TestListChangedNotifications(t *testing.T) {
+	server := NewServer("test-server", "1.0.0")
+	client := startTestServer(t, server)
+
+	noop := func(ctx context.Context, params json.RawMessage) (interface{}, error) { return nil, nil }
+
+	// Clients that have not finished initializing are not notified
+	server.RegisterTool(Tool{Name: "early"}, noop)
+	client.initialize()
+	if resp := client.call(1, "tools/list", nil); resp.Error != nil {
+		t.Fatalf("tools/list failed: %+v", resp.Error)
+	}
+
+	server.RegisterTool(Tool{Name: "late"}, noop)
+	if msg := client.read(); msg.Method != "notifications/tools/list_changed" {
+		t.Errorf("Expected tools list_changed, got %+v", msg)
+	}
+
+	if !server.UnregisterTool("late") {
+		t.Error("Expected UnregisterTool to find the tool")
+	}
+	if msg := client.read(); msg.Method != "notifications/tools/list_changed" {
+		t.Errorf("Expected tools list_changed, got %+v", msg)
+	}
+	if server.UnregisterTool("late") {
+		t.Error("Expected UnregisterTool to report a missing tool")
+	}
+
+	server.RegisterPrompt(Prompt{Name: "prompt"}, noop)
+	if msg := client.read(); msg.Method != "notifications/prompts/list_changed" {
+		t.Errorf("Expected prompts list_changed, got %+v", msg)
+	}
+	server.UnregisterResource("receptor://missing")
+	server.RegisterResource(Resource{URI: "receptor://r", Name: "r"}, noop)
+	if msg := client.read(); msg.Method != "notifications/resources/list_changed" {
+		t.Errorf("Expected resources list_changed, got %+v", msg)
+	}
+
+	// Unregistered tools can no longer be called
+	resp := client.call(2, "tools/call", ToolsCallRequest{Name: "late"})
+	var result ToolsCallResponse
+	json.Unmarshal(resp.Result, &result)
+	if resp.Error == nil && !result.IsError {
+		t.Errorf("Expected calling an unregistered tool to fail, got %s", resp.Result)
+	}
+}
@@ -0,0 +1,124 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// subscribe records the client's interest in a resource
+func (sess *session) subscribe(uri string) {
+	sess.subscriptionsMu.Lock()
+	defer sess.subscriptionsMu.Unlock()
+	if sess.subscriptions == nil {
+		sess.subscriptions = make(map[string]bool)
+	}
+	sess.subscriptions[uri] = true
+}
+
+// unsubscribe forgets the client's interest in a resource
+func (sess *session) unsubscribe(uri string) {
+	sess.subscriptionsMu.Lock()
+	defer sess.subscriptionsMu.Unlock()
+	delete(sess.subscriptions, uri)
+}
+
+// subscribed reports whether the client is subscribed to a resource
+func (sess *session) subscribed(uri string) bool {
+	sess.subscriptionsMu.Lock()
+	defer sess.subscriptionsMu.Unlock()
+	return sess.subscriptions[uri]
+}
+
+// HasSubscribers reports whether any connected client is subscribed to
+// uri, so callers can skip watching resources nobody is interested in
+func (s *Server) HasSubscribers(uri string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, sess := range s.sessions {
+		if sess.subscribed(uri) {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscriptions returns the URIs connected clients are subscribed to, so
+// callers can watch resources generated by templates
+func (s *Server) Subscriptions() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	seen := map[string]bool{}
+	for _, sess := range s.sessions {
+		sess.subscriptionsMu.Lock()
+		for uri := range sess.subscriptions {
+			seen[uri] = true
+		}
+		sess.subscriptionsMu.Unlock()
+	}
+	uris := mapKeys(seen)
+	sort.Strings(uris)
+	return uris
+}
+
+// NotifyResourceUpdated sends notifications/resources/updated to every
+// client subscribed to uri
+func (s *Server) NotifyResourceUpdated(uri string) {
+	s.mu.RLock()
+	var subscribers []*session
+	for _, sess := range s.sessions {
+		if sess.subscribed(uri) {
+			subscribers = append(subscribers, sess)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, sess := range subscribers {
+		if err := sess.notify("notifications/resources/updated", ResourceUpdatedNotification{URI: uri}); err != nil {
+			s.transportLog.Errorf("Error notifying session %s of update to %s: %v", sess.id, uri, err)
+		}
+	}
+}
+
+// resourceExists reports whether uri is a registered resource or is
+// generated by a registered template
+func (s *Server) resourceExists(uri string) bool {
+	s.mu.RLock()
+	_, exists := s.resources[uri]
+	s.mu.RUnlock()
+	if exists {
+		return true
+	}
+	template, _ := s.matchResourceTemplate(uri)
+	return template != nil
+}
+
+func (s *Server) handleResourcesSubscribe(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req ResourcesSubscribeRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+	if !s.resourceExists(req.URI) {
+		return nil, fmt.Errorf("resource not found: %s", req.URI)
+	}
+
+	sess := sessionFromContext(ctx)
+	if sess == nil {
+		return nil, fmt.Errorf("subscriptions require a session")
+	}
+	sess.subscribe(req.URI)
+	return map[string]interface{}{}, nil
+}
+
+func (s *Server) handleResourcesUnsubscribe(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req ResourcesUnsubscribeRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	if sess := sessionFromContext(ctx); sess != nil {
+		sess.unsubscribe(req.URI)
+	}
+	return map[string]interface{}{}, nil
+}
@@ -0,0 +1,89 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestResourceSubscriptions(t *testing.T) {
+	server := NewServer("test-server", "1.0.0")
+	server.RegisterResource(Resource{URI: "receptor://work/queue", Name: "queue"},
+		func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			return "queue", nil
+		})
+
+	client := startTestServer(t, server)
+	client.initialize()
+
+	if server.HasSubscribers("receptor://work/queue") {
+		t.Fatal("Expected no subscribers before subscribing")
+	}
+	resp := client.call(1, "resources/subscribe", ResourcesSubscribeRequest{URI: "receptor://work/queue"})
+	if resp.Error != nil {
+		t.Fatalf("resources/subscribe failed: %+v", resp.Error)
+	}
+	if !server.HasSubscribers("receptor://work/queue") {
+		t.Fatal("Expected a subscriber after subscribing")
+	}
+
+	// Only subscribed resources produce notifications
+	server.NotifyResourceUpdated("receptor://nodes/status")
+	server.NotifyResourceUpdated("receptor://work/queue")
+	msg := client.read()
+	if msg.Method != "notifications/resources/updated" {
+		t.Fatalf("Expected resource update notification, got %+v", msg)
+	}
+	var updated ResourceUpdatedNotification
+	if err := json.Unmarshal(msg.Params, &updated); err != nil || updated.URI != "receptor://work/queue" {
+		t.Errorf("Unexpected notification params: %s", msg.Params)
+	}
+
+	resp = client.call(2, "resources/unsubscribe", ResourcesUnsubscribeRequest{URI: "receptor://work/queue"})
+	if resp.Error != nil {
+		t.Fatalf("resources/unsubscribe failed: %+v", resp.Error)
+	}
+	server.NotifyResourceUpdated("receptor://work/queue")
+
+	// The next message is the response, not a notification
+	if resp := client.call(3, "resources/list", nil); resp.Error != nil {
+		t.Errorf("Unexpected error: %+v", resp.Error)
+	}
+}
+
+func TestSubscribeUnknownResource(t *testing.T) {
+	client := startTestServer(t, NewServer("test-server", "1.0.0"))
+	client.initialize()
+
+	resp := client.call(1, "resources/subscribe", ResourcesSubscribeRequest{URI: "receptor://nowhere"})
+	if resp.Error == nil {
+		t.Error("Expected error subscribing to an unknown resource")
+	}
+}
+
+func TestSubscriptionsIncludeTemplatedResources(t *testing.T) {
+	server := NewServer("test-server", "1.0.0")
+	server.RegisterResourceTemplate(ResourceTemplate{URITemplate: "receptor://work/{unit_id}/status", Name: "status"},
+		func(ctx context.Context, uri string, vars map[string]string) (interface{}, error) {
+			return "status of " + vars["unit_id"], nil
+		})
+
+	client := startTestServer(t, server)
+	client.initialize()
+	for i, uri := range []string{"receptor://work/unitB/status", "receptor://work/unitA/status"} {
+		if resp := client.call(i+1, "resources/subscribe", ResourcesSubscribeRequest{URI: uri}); resp.Error != nil {
+			t.Fatalf("resources/subscribe failed: %+v", resp.Error)
+		}
+	}
+
+	got := server.Subscriptions()
+	if len(got) != 2 || got[0] != "receptor://work/unitA/status" || got[1] != "receptor://work/unitB/status" {
+		t.Errorf("Expected both subscriptions, sorted, got %v", got)
+	}
+	if result, err := server.ReadResource(context.Background(), got[0]); err != nil || result != "status of unitA" {
+		t.Errorf("Expected the templated resource to be read, got %v, %v", result, err)
+	}
+	if _, err := server.ReadResource(context.Background(), "receptor://nowhere"); err == nil {
+		t.Error("Expected an error reading an unknown resource")
+	}
+}
@@ -0,0 +1,148 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ResourceTemplateHandler reads a resource generated from a template. It
+// receives the requested URI and the values of the template's variables.
+type ResourceTemplateHandler func(ctx context.Context, uri string, vars map[string]string) (interface{}, error)
+
+// resourceTemplate is a registered template with its compiled matcher
+type resourceTemplate struct {
+	template ResourceTemplate
+	pattern  *regexp.Regexp
+	handler  ResourceTemplateHandler
+}
+
+// templateVariable matches a simple {name} expression of a URI template
+var templateVariable = regexp.MustCompile(`\{([A-Za-z0-9_]+)\}`)
+
+// compileURITemplate turns a URI template into a regular expression
+// matching the URIs it describes. Only simple {name} expressions are
+// supported; each matches a single non-empty path segment.
+func compileURITemplate(template string) (*regexp.Regexp, error) {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	seen := map[string]bool{}
+	last := 0
+	for _, loc := range templateVariable.FindAllStringSubmatchIndex(template, -1) {
+		literal := template[last:loc[0]]
+		if strings.ContainsAny(literal, "{}") {
+			return nil, fmt.Errorf("unsupported expression in URI template %q", template)
+		}
+		name := template[loc[2]:loc[3]]
+		if seen[name] {
+			return nil, fmt.Errorf("variable %q repeated in URI template %q", name, template)
+		}
+		seen[name] = true
+		pattern.WriteString(regexp.QuoteMeta(literal))
+		pattern.WriteString("(?P<" + name + ">[^/?#]+)")
+		last = loc[1]
+	}
+	if strings.ContainsAny(template[last:], "{}") {
+		return nil, fmt.Errorf("unsupported expression in URI template %q", template)
+	}
+	pattern.WriteString(regexp.QuoteMeta(template[last:]))
+	pattern.WriteString("$")
+	return regexp.Compile(pattern.String())
+}
+
+// match reports whether uri was generated by the template, returning the
+// decoded values of its variables
+func (t *resourceTemplate) match(uri string) (map[string]string, bool) {
+	groups := t.pattern.FindStringSubmatch(uri)
+	if groups == nil {
+		return nil, false
+	}
+	vars := make(map[string]string, len(groups)-1)
+	for i, name := range t.pattern.SubexpNames() {
+		if i == 0 {
+			continue
+		}
+		value, err := url.PathUnescape(groups[i])
+		if err != nil {
+			return nil, false
+		}
+		vars[name] = value
+	}
+	return vars, true
+}
+
+// RegisterResourceTemplate registers a resource template, such as
+// receptor://work/{unit_id}/status. resources/read requests for URIs that
+// are not registered resources but match the template are passed to
+// handler with the matched variables. It returns an error if the template
+// uses expressions other than {name}.
+func (s *Server) RegisterResourceTemplate(template ResourceTemplate, handler ResourceTemplateHandler) error {
+	pattern, err := compileURITemplate(template.URITemplate)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.templates[template.URITemplate] = &resourceTemplate{
+		template: template,
+		pattern:  pattern,
+		handler:  handler,
+	}
+	s.mu.Unlock()
+	s.notifyListChanged("notifications/resources/list_changed")
+	return nil
+}
+
+// UnregisterResourceTemplate removes a resource template, reporting
+// whether it was registered
+func (s *Server) UnregisterResourceTemplate(uriTemplate string) bool {
+	s.mu.Lock()
+	_, exists := s.templates[uriTemplate]
+	delete(s.templates, uriTemplate)
+	s.mu.Unlock()
+	if exists {
+		s.notifyListChanged("notifications/resources/list_changed")
+	}
+	return exists
+}
+
+// matchResourceTemplate finds the template that generates uri. Templates
+// are tried in sorted order so overlapping templates resolve consistently.
+func (s *Server) matchResourceTemplate(uri string) (*resourceTemplate, map[string]string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := mapKeys(s.templates)
+	sort.Strings(keys)
+	for _, key := range keys {
+		if vars, ok := s.templates[key].match(uri); ok {
+			return s.templates[key], vars
+		}
+	}
+	return nil, nil
+}
+
+func (s *Server) handleResourceTemplatesList(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req ResourceTemplatesListRequest
+	if err := parseParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	page, nextCursor, err := paginate(mapKeys(s.templates), req.Cursor, s.pageSize)
+	if err != nil {
+		return nil, err
+	}
+	templates := make([]ResourceTemplate, 0, len(page))
+	for _, key := range page {
+		templates = append(templates, s.templates[key].template)
+	}
+
+	return ResourceTemplatesListResponse{ResourceTemplates: templates, NextCursor: nextCursor}, nil
+}
@@ -0,0 +1,91 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestCompileURITemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		uri      string
+		vars     map[string]string
+	}{
+		{"receptor://nodes/{node_id}", "receptor://nodes/worker-01", map[string]string{"node_id": "worker-01"}},
+		{"receptor://nodes/{node_id}", "receptor://nodes/worker-01/extra", nil},
+		{"receptor://nodes/{node_id}", "receptor://nodes/", nil},
+		{"receptor://work/{unit_id}/stdout", "receptor://work/Ab12/stdout", map[string]string{"unit_id": "Ab12"}},
+		{"receptor://work/{unit_id}/stdout", "receptor://work/Ab12/status", nil},
+		{"receptor://work/{unit_id}/stdout", "receptor://work/a%20b/stdout", map[string]string{"unit_id": "a b"}},
+		{"file://{a}.{b}", "file://x.y", map[string]string{"a": "x", "b": "y"}},
+	}
+
+	for _, tt := range tests {
+		pattern, err := compileURITemplate(tt.template)
+		if err != nil {
+			t.Fatalf("compileURITemplate(%q) returned error: %v", tt.template, err)
+		}
+		vars, ok := (&resourceTemplate{pattern: pattern}).match(tt.uri)
+		if ok != (tt.vars != nil) {
+			t.Errorf("%q matching %q: expected match %v", tt.template, tt.uri, tt.vars != nil)
+			continue
+		}
+		for name, value := range tt.vars {
+			if vars[name] != value {
+				t.Errorf("%q matching %q: expected %s=%q, got %q", tt.template, tt.uri, name, value, vars[name])
+			}
+		}
+	}
+}
+
+func TestCompileURITemplateUnsupported(t *testing.T) {
+	for _, template := range []string{"receptor://{+path}", "receptor://{a}/{a}", "receptor://{unclosed"} {
+		if _, err := compileURITemplate(template); err == nil {
+			t.Errorf("Expected error for %q", template)
+		}
+	}
+}
+
+func TestResourceTemplateRead(t *testing.T) {
+	server := NewServer("test-server", "1.0.0")
+	server.RegisterResource(Resource{URI: "receptor://work/queue", Name: "queue"},
+		func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			return "queue", nil
+		})
+	err := server.RegisterResourceTemplate(ResourceTemplate{
+		URITemplate: "receptor://work/{unit_id}",
+		Name:        "Work Unit",
+	}, func(ctx context.Context, uri string, vars map[string]string) (interface{}, error) {
+		return uri + " " + vars["unit_id"], nil
+	})
+	if err != nil {
+		t.Fatalf("RegisterResourceTemplate returned error: %v", err)
+	}
+	ctx := context.Background()
+
+	read := func(uri string) (interface{}, error) {
+		params, _ := json.Marshal(ResourcesReadRequest{URI: uri})
+		return server.handleResourcesRead(ctx, params)
+	}
+
+	// Registered resources take precedence over templates
+	if result, err := read("receptor://work/queue"); err != nil || result != "queue" {
+		t.Errorf("Expected fixed resource, got %v, %v", result, err)
+	}
+	if result, err := read("receptor://work/unitA"); err != nil || result != "receptor://work/unitA unitA" {
+		t.Errorf("Expected template resource, got %v, %v", result, err)
+	}
+	if _, err := read("receptor://nodes/unitA"); err == nil {
+		t.Error("Expected error for unmatched URI")
+	}
+
+	result, err := server.handleResourceTemplatesList(ctx, nil)
+	if err != nil {
+		t.Fatalf("resources/templates/list returned error: %v", err)
+	}
+	templates := result.(ResourceTemplatesListResponse).ResourceTemplates
+	if len(templates) != 1 || templates[0].URITemplate != "receptor://work/{unit_id}" {
+		t.Errorf("Unexpected templates: %+v", templates)
+	}
+}
@@ -0,0 +1,155 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// Transport carries encoded JSON-RPC messages between a client and the
+// server. ReadMessage returns io.EOF once the client has disconnected.
+// WriteMessage must be safe for concurrent use, since responses are written
+// from concurrently running handlers.
+type Transport interface {
+	ReadMessage(ctx context.Context) ([]byte, error)
+	WriteMessage(ctx context.Context, data []byte) error
+	Close() error
+}
+
+// StreamTransport exchanges newline-delimited JSON-RPC messages over an
+// io.Reader and io.Writer, as the MCP stdio transport specifies
+type StreamTransport struct {
+	reader *bufio.Reader
+
+	mu     sync.Mutex
+	writer *bufio.Writer
+}
+
+// NewStreamTransport creates a transport reading messages from r and
+// writing them to w. Closing the transport does not close r or w.
+func NewStreamTransport(r io.Reader, w io.Writer) *StreamTransport {
+	return &StreamTransport{
+		reader: bufio.NewReader(r),
+		writer: bufio.NewWriter(w),
+	}
+}
+
+// NewStdioTransport creates a transport over the process's stdin and stdout
+func NewStdioTransport() *StreamTransport {
+	return NewStreamTransport(os.Stdin, os.Stdout)
+}
+
+// ReadMessage implements Transport. Blank lines are skipped. The read
+// itself cannot be interrupted by ctx.
+func (t *StreamTransport) ReadMessage(ctx context.Context) ([]byte, error) {
+	for {
+		line, err := t.reader.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			return line, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// WriteMessage implements Transport. Each message is written whole, so
+// concurrent writers never interleave.
+func (t *StreamTransport) WriteMessage(ctx context.Context, data []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, err := t.writer.Write(data); err != nil {
+		return err
+	}
+	if err := t.writer.WriteByte('\n'); err != nil {
+		return err
+	}
+	return t.writer.Flush()
+}
+
+// Close implements Transport by flushing buffered output
+func (t *StreamTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.writer.Flush()
+}
+
+// pipeBuffer is how many messages each direction of a pipe holds before
+// writers block
+const pipeBuffer = 64
+
+// pipeTransport is one end of an in-memory message pipe
+type pipeTransport struct {
+	in     <-chan []byte
+	out    chan<- []byte
+	done   chan struct{}
+	closer *sync.Once
+}
+
+// NewPipeTransport returns two connected in-memory transports. Messages
+// written to one end are read from the other; closing either end closes
+// both. It lets tests and Go programs drive a server without stdio.
+func NewPipeTransport() (client, server Transport) {
+	toServer := make(chan []byte, pipeBuffer)
+	toClient := make(chan []byte, pipeBuffer)
+	done := make(chan struct{})
+	closer := &sync.Once{}
+
+	client = &pipeTransport{in: toClient, out: toServer, done: done, closer: closer}
+	server = &pipeTransport{in: toServer, out: toClient, done: done, closer: closer}
+	return client, server
+}
+
+// ReadMessage implements Transport. Messages already in flight are still
+// delivered after the pipe is closed.
+func (p *pipeTransport) ReadMessage(ctx context.Context) ([]byte, error) {
+	select {
+	case data := <-p.in:
+		return data, nil
+	default:
+	}
+
+	select {
+	case data := <-p.in:
+		return data, nil
+	case <-p.done:
+		return nil, io.EOF
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// WriteMessage implements Transport
+func (p *pipeTransport) WriteMessage(ctx context.Context, data []byte) error {
+	msg := append([]byte(nil), data...)
+	select {
+	case <-p.done:
+		return io.ErrClosedPipe
+	default:
+	}
+
+	select {
+	case p.out <- msg:
+		return nil
+	case <-p.done:
+		return io.ErrClosedPipe
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close implements Transport
+func (p *pipeTransport) Close() error {
+	p.closer.Do(func() { close(p.done) })
+	return nil
+}
+
+// isClosedError reports whether err means the transport has been closed
+func isClosedError(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrClosedPipe)
+}
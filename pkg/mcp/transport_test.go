@@ -0,0 +1,272 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testMessage is any message a test client can receive from the server
+type testMessage struct {
+	ID     interface{}     `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *JSONRPCError   `json:"error"`
+}
+
+// testClient drives a running server over an in-memory pipe
+type testClient struct {
+	t         *testing.T
+	transport Transport
+}
+
+// startTestServer runs server on a pipe transport for the duration of the test
+func startTestServer(t *testing.T, server *Server) *testClient {
+	t.Helper()
+	client, serverTransport := NewPipeTransport()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.Run(ctx, serverTransport) }()
+
+	t.Cleanup(func() {
+		client.Close()
+		cancel()
+		<-done
+	})
+	return &testClient{t: t, transport: client}
+}
+
+// send writes a message to the server
+func (c *testClient) send(msg interface{}) {
+	c.t.Helper()
+	data, err := json.Marshal(msg)
+	if err != nil {
+		c.t.Fatalf("Failed to marshal message: %v", err)
+	}
+	if err := c.transport.WriteMessage(context.Background(), data); err != nil {
+		c.t.Fatalf("Failed to send message: %v", err)
+	}
+}
+
+// read returns the next message from the server
+func (c *testClient) read() testMessage {
+	c.t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	data, err := c.transport.ReadMessage(ctx)
+	if err != nil {
+		c.t.Fatalf("Failed to read message: %v", err)
+	}
+	var msg testMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		c.t.Fatalf("Failed to decode message %s: %v", data, err)
+	}
+	return msg
+}
+
+// call sends a request and waits for its response
+func (c *testClient) call(id int, method string, params interface{}) testMessage {
+	c.t.Helper()
+	c.send(JSONRPCRequest{JSONRPC: "2.0", ID: id, Method: method, Params: params})
+	msg := c.read()
+	if msg.ID != float64(id) {
+		c.t.Fatalf("Expected response to request %d, got %+v", id, msg)
+	}
+	return msg
+}
+
+// initialize performs the initialize handshake at the latest protocol version
+func (c *testClient) initialize() {
+	c.t.Helper()
+	c.initializeWith(MCPVersion, ClientCapabilities{})
+}
+
+// initializeWith performs the initialize handshake requesting version and
+// declaring capabilities, and returns the server's response
+func (c *testClient) initializeWith(version string, capabilities ClientCapabilities) InitializeResponse {
+	c.t.Helper()
+	resp := c.call(0, "initialize", InitializeRequest{
+		ProtocolVersion: version,
+		Capabilities:    capabilities,
+		ClientInfo:      ClientInfo{Name: "test-client", Version: "1.0.0"},
+	})
+	if resp.Error != nil {
+		c.t.Fatalf("initialize failed: %+v", resp.Error)
+	}
+	var result InitializeResponse
+	json.Unmarshal(resp.Result, &result)
+	c.send(JSONRPCNotification{JSONRPC: "2.0", Method: "notifications/initialized"})
+	return result
+}
+
+func TestEndToEndToolCall(t *testing.T) {
+	server := NewServer("test-server", "1.0.0")
+	server.RegisterTool(Tool{
+		Name:        "echo",
+		Description: "Echo the message argument",
+		InputSchema: map[string]interface{}{"type": "object"},
+	}, func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		var args struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(params, &args)
+		return "echo: " + args.Message, nil
+	})
+
+	client := startTestServer(t, server)
+	client.initialize()
+
+	resp := client.call(1, "tools/list", nil)
+	var list ToolsListResponse
+	if err := json.Unmarshal(resp.Result, &list); err != nil {
+		t.Fatalf("Failed to decode tools/list result: %v", err)
+	}
+	if len(list.Tools) != 1 || list.Tools[0].Name != "echo" {
+		t.Fatalf("Unexpected tools: %+v", list.Tools)
+	}
+
+	resp = client.call(2, "tools/call", ToolsCallRequest{
+		Name:      "echo",
+		Arguments: map[string]interface{}{"message": "hello"},
+	})
+	var result ToolsCallResponse
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("Failed to decode tools/call result: %v", err)
+	}
+	if result.IsError || len(result.Content) != 1 || result.Content[0].Text != "echo: hello" {
+		t.Errorf("Unexpected tools/call result: %+v", result)
+	}
+}
+
+func TestEndToEndErrors(t *testing.T) {
+	client := startTestServer(t, NewServer("test-server", "1.0.0"))
+	client.initialize()
+
+	resp := client.call(1, "no/such/method", nil)
+	if resp.Error == nil || resp.Error.Code != MethodNotFound {
+		t.Errorf("Expected MethodNotFound, got %+v", resp.Error)
+	}
+
+	client.transport.WriteMessage(context.Background(), []byte("{not json"))
+	resp = client.read()
+	if resp.Error == nil || resp.Error.Code != ParseError {
+		t.Errorf("Expected ParseError, got %+v", resp.Error)
+	}
+}
+
+func TestRunReturnsOnDisconnect(t *testing.T) {
+	client, serverTransport := NewPipeTransport()
+	done := make(chan error, 1)
+	go func() { done <- NewServer("test-server", "1.0.0").Run(context.Background(), serverTransport) }()
+
+	client.Close()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected nil error on disconnect, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the client disconnected")
+	}
+}
+
+func TestStreamTransport(t *testing.T) {
+	input := strings.NewReader("{\"a\":1}\n\n  {\"b\":2}  \n{\"c\":3}")
+	var output bytes.Buffer
+	transport := NewStreamTransport(input, &output)
+	ctx := context.Background()
+
+	for _, expected := range []string{`{"a":1}`, `{"b":2}`, `{"c":3}`} {
+		data, err := transport.ReadMessage(ctx)
+		if err != nil {
+			t.Fatalf("ReadMessage returned error: %v", err)
+		}
+		if string(data) != expected {
+			t.Errorf("Expected %s, got %s", expected, data)
+		}
+	}
+	if _, err := transport.ReadMessage(ctx); !errors.Is(err, io.EOF) {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+
+	transport.WriteMessage(ctx, []byte(`{"x":1}`))
+	transport.WriteMessage(ctx, []byte(`{"y":2}`))
+	if output.String() != "{\"x\":1}\n{\"y\":2}\n" {
+		t.Errorf("Unexpected output: %q", output.String())
+	}
+}
+
+func TestPipeTransportClose(t *testing.T) {
+	client, server := NewPipeTransport()
+	ctx := context.Background()
+
+	client.WriteMessage(ctx, []byte("queued"))
+	server.Close()
+
+	// Messages in flight are still delivered before EOF
+	data, err := server.ReadMessage(ctx)
+	if err != nil || string(data) != "queued" {
+		t.Errorf("Expected queued message, got %q, %v", data, err)
+	}
+	if _, err := server.ReadMessage(ctx); !errors.Is(err, io.EOF) {
+		t.Errorf("Expected io.EOF after close, got %v", err)
+	}
+	if err := client.WriteMessage(ctx, []byte("late")); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("Expected io.ErrClosedPipe, got %v", err)
+	}
+}
+
+func TestCancelledRequest(t *testing.T) {
+	server := NewServer("test-server", "1.0.0")
+	started := make(chan struct{})
+	stopped := make(chan error, 1)
+	server.RegisterTool(Tool{Name: "block", InputSchema: map[string]interface{}{"type": "object"}},
+		func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			close(started)
+			<-ctx.Done()
+			stopped <- ctx.Err()
+			return nil, ctx.Err()
+		})
+
+	client := startTestServer(t, server)
+	client.initialize()
+
+	client.send(JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "tools/call", Params: ToolsCallRequest{Name: "block"}})
+	<-started
+	client.send(JSONRPCNotification{
+		JSONRPC: "2.0",
+		Method:  "notifications/cancelled",
+		Params:  CancelledNotification{RequestID: 1, Reason: "user abort"},
+	})
+
+	select {
+	case err := <-stopped:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled in handler, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Handler was not cancelled")
+	}
+
+	// The cancelled request gets no response; the next request does
+	resp := client.call(2, "tools/list", nil)
+	if resp.Error != nil {
+		t.Errorf("Unexpected error: %+v", resp.Error)
+	}
+
+	// Cancelling an unknown or finished request is ignored
+	client.send(JSONRPCNotification{
+		JSONRPC: "2.0",
+		Method:  "notifications/cancelled",
+		Params:  CancelledNotification{RequestID: 2},
+	})
+	if resp := client.call(3, "tools/list", nil); resp.Error != nil {
+		t.Errorf("Unexpected error: %+v", resp.Error)
+	}
+}
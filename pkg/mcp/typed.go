@@ -0,0 +1,263 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// TypedHandler handles a tool call with arguments decoded into Args
+type TypedHandler[Args, Result any] func(ctx context.Context, args Args) (Result, error)
+
+// RegisterTypedTool registers a tool whose input schema is derived from the
+// Args struct, and whose arguments are decoded into Args before handler is
+// called. If tool.InputSchema is already set it is used as is. When Result
+// is a struct, or a pointer to one, its schema becomes the tool's
+// OutputSchema unless that is already set.
+//
+// Fields are named by their json tags and described by these tags:
+//
+//	description:"..."  the property description
+//	required:"true"    the property must be present
+//	enum:"a,b,c"       the allowed values
+//	default:"value"    the value used when the property is absent
+//
+// RegisterTypedTool panics if Args is not a struct or has a field with no
+// JSON Schema equivalent, since that is a programming error.
+func RegisterTypedTool[Args, Result any](s *Server, tool Tool, handler TypedHandler[Args, Result]) {
+	if tool.InputSchema == nil {
+		schema, err := SchemaFor[Args]()
+		if err != nil {
+			panic(fmt.Sprintf("mcp: tool %s: %v", tool.Name, err))
+		}
+		tool.InputSchema = schema
+	}
+	if tool.OutputSchema == nil {
+		t := reflect.TypeOf((*Result)(nil)).Elem()
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct {
+			schema, err := typeSchema(t)
+			if err != nil {
+				panic(fmt.Sprintf("mcp: tool %s: %v", tool.Name, err))
+			}
+			tool.OutputSchema = schema
+		}
+	}
+
+	s.RegisterTool(tool, func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		args, err := decodeArgs[Args](params)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, args)
+	})
+}
+
+// SchemaFor derives a JSON Schema for the struct type T from its fields and
+// tags, as described for RegisterTypedTool
+func SchemaFor[T any]() (map[string]interface{}, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("arguments must be a struct, not %s", t)
+	}
+	return typeSchema(t)
+}
+
+// decodeArgs decodes tool arguments into Args, starting from its defaults
+func decodeArgs[Args any](params json.RawMessage) (Args, error) {
+	var args Args
+	if err := applyDefaults(reflect.ValueOf(&args).Elem()); err != nil {
+		return args, err
+	}
+	if len(params) == 0 || string(params) == "null" {
+		return args, nil
+	}
+	if err := json.Unmarshal(params, &args); err != nil {
+		return args, fmt.Errorf("invalid arguments: %w", err)
+	}
+	return args, nil
+}
+
+// typeSchema returns the JSON Schema describing values of type t
+func typeSchema(t reflect.Type) (map[string]interface{}, error) {
+	switch t {
+	case reflect.TypeOf(json.RawMessage(nil)):
+		return map[string]interface{}{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeSchema(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}, nil
+	case reflect.Interface:
+		return map[string]interface{}{}, nil
+	case reflect.Slice, reflect.Array:
+		items, err := typeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("map key type %s is not a string", t.Key())
+		}
+		values, err := typeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		schema := map[string]interface{}{"type": "object"}
+		if len(values) > 0 {
+			schema["additionalProperties"] = values
+		}
+		return schema, nil
+	case reflect.Struct:
+		return structSchema(t)
+	}
+	return nil, fmt.Errorf("type %s has no JSON Schema equivalent", t)
+}
+
+// structSchema returns the object schema for a struct type
+func structSchema(t reflect.Type) (map[string]interface{}, error) {
+	properties := map[string]interface{}{}
+	required := []string{}
+	err := eachField(t, func(field reflect.StructField, name string) error {
+		schema, err := typeSchema(field.Type)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if description := field.Tag.Get("description"); description != "" {
+			schema["description"] = description
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			var values []interface{}
+			for _, raw := range strings.Split(enum, ",") {
+				value, err := parseTagValue(field.Type, strings.TrimSpace(raw))
+				if err != nil {
+					return fmt.Errorf("field %s: invalid enum value: %w", field.Name, err)
+				}
+				values = append(values, value)
+			}
+			schema["enum"] = values
+		}
+		if def, ok := field.Tag.Lookup("default"); ok {
+			value, err := parseTagValue(field.Type, def)
+			if err != nil {
+				return fmt.Errorf("field %s: invalid default: %w", field.Name, err)
+			}
+			schema["default"] = value
+		}
+		if field.Tag.Get("required") == "true" {
+			required = append(required, name)
+		}
+		properties[name] = schema
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema, nil
+}
+
+// eachField calls fn for every field of struct type t that encoding/json
+// would encode, with its JSON name. Fields of embedded structs are visited
+// as if they were fields of t.
+// The Index of each field passed to fn is relative to t.
+func eachField(t reflect.Type, fn func(field reflect.StructField, name string) error) error {
+	return eachFieldIn(t, nil, fn)
+}
+
+func eachFieldIn(t reflect.Type, index []int, fn func(field reflect.StructField, name string) error) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		field.Index = append(append([]int{}, index...), i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			if err := eachFieldIn(field.Type, field.Index, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if err := fn(field, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseTagValue parses a tag value as a value of the field's type
+func parseTagValue(t reflect.Type, raw string) (interface{}, error) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return raw, nil
+	case reflect.Bool:
+		return strconv.ParseBool(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseInt(raw, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(raw, 64)
+	}
+	return nil, fmt.Errorf("%s fields cannot have tag values", t)
+}
+
+// applyDefaults sets the fields of struct v that have default tags
+func applyDefaults(v reflect.Value) error {
+	return eachField(v.Type(), func(field reflect.StructField, name string) error {
+		fieldValue := v.FieldByIndex(field.Index)
+		if field.Type.Kind() == reflect.Struct {
+			return applyDefaults(fieldValue)
+		}
+		def, ok := field.Tag.Lookup("default")
+		if !ok {
+			return nil
+		}
+		value, err := parseTagValue(field.Type, def)
+		if err != nil {
+			return fmt.Errorf("field %s: invalid default: %w", field.Name, err)
+		}
+		target := fieldValue
+		if field.Type.Kind() == reflect.Pointer {
+			target = reflect.New(field.Type.Elem()).Elem()
+		}
+		target.Set(reflect.ValueOf(value).Convert(target.Type()))
+		if field.Type.Kind() == reflect.Pointer {
+			fieldValue.Set(target.Addr())
+		}
+		return nil
+	})
+}
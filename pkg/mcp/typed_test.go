@@ -0,0 +1,149 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type commonArgs struct {
+	Verbose bool `json:"verbose" description:"Include extra detail"`
+}
+
+type testSubmitArgs struct {
+	commonArgs
+	NodeID   string            `json:"node_id" description:"Target node" required:"true"`
+	Priority string            `json:"priority,omitempty" enum:"low,normal,high" default:"normal"`
+	Retries  int               `json:"retries" default:"2"`
+	Tags     []string          `json:"tags"`
+	Params   map[string]string `json:"params"`
+	Limits   struct {
+		CPU float64 `json:"cpu" required:"true"`
+	} `json:"limits"`
+	Internal string `json:"-"`
+	hidden   string
+}
+
+func TestSchemaFor(t *testing.T) {
+	schema, err := SchemaFor[testSubmitArgs]()
+	if err != nil {
+		t.Fatalf("SchemaFor returned error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"verbose":  map[string]interface{}{"type": "boolean", "description": "Include extra detail"},
+			"node_id":  map[string]interface{}{"type": "string", "description": "Target node"},
+			"priority": map[string]interface{}{"type": "string", "enum": []interface{}{"low", "normal", "high"}, "default": "normal"},
+			"retries":  map[string]interface{}{"type": "integer", "default": int64(2)},
+			"tags":     map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			"params": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": map[string]interface{}{"type": "string"},
+			},
+			"limits": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"cpu": map[string]interface{}{"type": "number"},
+				},
+				"required": []string{"cpu"},
+			},
+		},
+		"required": []string{"node_id"},
+	}
+	if !reflect.DeepEqual(schema, expected) {
+		got, _ := json.MarshalIndent(schema, "", "  ")
+		t.Errorf("Unexpected schema:\n%s", got)
+	}
+}
+
+func TestSchemaForErrors(t *testing.T) {
+	if _, err := SchemaFor[string](); err == nil {
+		t.Error("Expected error for a non-struct type")
+	}
+	if _, err := SchemaFor[struct {
+		Callback func() `json:"callback"`
+	}](); err == nil {
+		t.Error("Expected error for a func field")
+	}
+	if _, err := SchemaFor[struct {
+		Count int `json:"count" default:"many"`
+	}](); err == nil {
+		t.Error("Expected error for an invalid default")
+	}
+}
+
+func TestRegisterTypedTool(t *testing.T) {
+	server := NewServer("test-server", "1.0.0")
+	var received testSubmitArgs
+	RegisterTypedTool(server, Tool{Name: "submit", Description: "Submit work"},
+		func(ctx context.Context, args testSubmitArgs) (map[string]interface{}, error) {
+			received = args
+			return map[string]interface{}{"node": args.NodeID}, nil
+		})
+
+	client := startTestServer(t, server)
+	client.initialize()
+
+	resp := client.call(1, "tools/list", nil)
+	var list ToolsListResponse
+	json.Unmarshal(resp.Result, &list)
+	if len(list.Tools) != 1 || list.Tools[0].InputSchema["required"] == nil {
+		t.Fatalf("Expected derived input schema, got %+v", list.Tools)
+	}
+
+	// Missing required arguments are rejected before the handler runs
+	resp = client.call(2, "tools/call", ToolsCallRequest{Name: "submit", Arguments: map[string]interface{}{}})
+	if resp.Error == nil || resp.Error.Code != InvalidParams {
+		t.Fatalf("Expected InvalidParams, got %+v", resp)
+	}
+
+	resp = client.call(3, "tools/call", ToolsCallRequest{Name: "submit", Arguments: map[string]interface{}{
+		"node_id": "worker-01",
+		"retries": 5,
+		"limits":  map[string]interface{}{"cpu": 1.5},
+	}})
+	if resp.Error != nil {
+		t.Fatalf("tools/call failed: %+v", resp.Error)
+	}
+	if received.NodeID != "worker-01" || received.Retries != 5 || received.Limits.CPU != 1.5 {
+		t.Errorf("Arguments not decoded: %+v", received)
+	}
+	if received.Priority != "normal" {
+		t.Errorf("Expected default priority, got %q", received.Priority)
+	}
+}
+
+func TestRegisterTypedToolOutputSchema(t *testing.T) {
+	type workStatus struct {
+		WorkID string `json:"work_id" description:"Work unit ID" required:"true"`
+		State  string `json:"state"`
+	}
+
+	server := NewServer("test-server", "1.0.0")
+	RegisterTypedTool(server, Tool{Name: "status"},
+		func(ctx context.Context, args struct{}) (*workStatus, error) {
+			return &workStatus{WorkID: "unitA", State: "Succeeded"}, nil
+		})
+
+	client := startTestServer(t, server)
+	client.initialize()
+
+	resp := client.call(1, "tools/list", nil)
+	var list ToolsListResponse
+	json.Unmarshal(resp.Result, &list)
+	properties, _ := list.Tools[0].OutputSchema["properties"].(map[string]interface{})
+	if properties["work_id"] == nil || properties["state"] == nil {
+		t.Fatalf("Expected derived output schema, got %+v", list.Tools[0].OutputSchema)
+	}
+
+	resp = client.call(2, "tools/call", ToolsCallRequest{Name: "status"})
+	var result ToolsCallResponse
+	json.Unmarshal(resp.Result, &result)
+	structured, _ := result.StructuredContent.(map[string]interface{})
+	if structured["work_id"] != "unitA" || structured["state"] != "Succeeded" {
+		t.Errorf("Expected structured content, got %+v", result.StructuredContent)
+	}
+}
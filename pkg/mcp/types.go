@@ -1,7 +1,14 @@
 package mcp
 
-// MCP Protocol Version
-const MCPVersion = "2024-11-05"
+// MCP protocol revisions the server can negotiate
+const (
+	ProtocolVersion20241105 = "2024-11-05"
+	ProtocolVersion20250326 = "2025-03-26"
+	ProtocolVersion20250618 = "2025-06-18"
+)
+
+// MCPVersion is the latest protocol revision the server supports
+const MCPVersion = ProtocolVersion20250618
 
 // JSON-RPC 2.0 Message Types
 type JSONRPCRequest struct {
@@ -24,12 +31,38 @@ type JSONRPCError struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
+// Error implements error, so handlers can fail with a specific JSON-RPC
+// error code rather than InternalError
+func (e *JSONRPCError) Error() string {
+	return e.Message
+}
+
 type JSONRPCNotification struct {
 	JSONRPC string      `json:"jsonrpc"`
 	Method  string      `json:"method"`
 	Params  interface{} `json:"params,omitempty"`
 }
 
+// CancelledNotification is sent by the client to abandon an in-flight request
+type CancelledNotification struct {
+	RequestID interface{} `json:"requestId"`
+	Reason    string      `json:"reason,omitempty"`
+}
+
+// RequestMeta is the _meta object a client may attach to request params
+type RequestMeta struct {
+	ProgressToken interface{} `json:"progressToken,omitempty"`
+}
+
+// ProgressNotification reports progress on a request that carried a
+// progress token
+type ProgressNotification struct {
+	ProgressToken interface{} `json:"progressToken"`
+	Progress      float64     `json:"progress"`
+	Total         float64     `json:"total,omitempty"`
+	Message       string      `json:"message,omitempty"`
+}
+
 // MCP Initialize Request/Response
 type InitializeRequest struct {
 	ProtocolVersion string                 `json:"protocolVersion"`
@@ -44,15 +77,17 @@ type InitializeResponse struct {
 }
 
 type ClientCapabilities struct {
-	Roots    *RootsCapability    `json:"roots,omitempty"`
-	Sampling *SamplingCapability `json:"sampling,omitempty"`
+	Roots       *RootsCapability       `json:"roots,omitempty"`
+	Sampling    *SamplingCapability    `json:"sampling,omitempty"`
+	Elicitation *ElicitationCapability `json:"elicitation,omitempty"`
 }
 
 type ServerCapabilities struct {
-	Logging   *LoggingCapability   `json:"logging,omitempty"`
-	Prompts   *PromptsCapability   `json:"prompts,omitempty"`
-	Resources *ResourcesCapability `json:"resources,omitempty"`
-	Tools     *ToolsCapability     `json:"tools,omitempty"`
+	Logging     *LoggingCapability     `json:"logging,omitempty"`
+	Completions *CompletionsCapability `json:"completions,omitempty"`
+	Prompts     *PromptsCapability     `json:"prompts,omitempty"`
+	Resources   *ResourcesCapability   `json:"resources,omitempty"`
+	Tools       *ToolsCapability       `json:"tools,omitempty"`
 }
 
 type RootsCapability struct {
@@ -61,8 +96,12 @@ type RootsCapability struct {
 
 type SamplingCapability struct{}
 
+type ElicitationCapability struct{}
+
 type LoggingCapability struct{}
 
+type CompletionsCapability struct{}
+
 type PromptsCapability struct {
 	ListChanged bool `json:"listChanged,omitempty"`
 }
@@ -91,22 +130,43 @@ type Tool struct {
 	Name        string                 `json:"name"`
 	Description string                 `json:"description"`
 	InputSchema map[string]interface{} `json:"inputSchema"`
+	// OutputSchema optionally describes the tool's structured results
+	OutputSchema map[string]interface{} `json:"outputSchema,omitempty"`
+	Annotations  *ToolAnnotations       `json:"annotations,omitempty"`
+}
+
+// ToolAnnotations are hints about a tool's behavior. Unset hints take the
+// protocol defaults: not read-only, destructive, not idempotent and open
+// world.
+type ToolAnnotations struct {
+	Title           string `json:"title,omitempty"`
+	ReadOnlyHint    *bool  `json:"readOnlyHint,omitempty"`
+	DestructiveHint *bool  `json:"destructiveHint,omitempty"`
+	IdempotentHint  *bool  `json:"idempotentHint,omitempty"`
+	OpenWorldHint   *bool  `json:"openWorldHint,omitempty"`
 }
 
-type ToolsListRequest struct{}
+type ToolsListRequest struct {
+	Cursor string `json:"cursor,omitempty"`
+}
 
 type ToolsListResponse struct {
-	Tools []Tool `json:"tools"`
+	Tools      []Tool `json:"tools"`
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 type ToolsCallRequest struct {
 	Name      string                 `json:"name"`
 	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Meta      *RequestMeta           `json:"_meta,omitempty"`
 }
 
 type ToolsCallResponse struct {
 	Content []Content `json:"content"`
-	IsError bool      `json:"isError,omitempty"`
+	// StructuredContent is the result as a JSON object, for tools that
+	// return structured data
+	StructuredContent interface{} `json:"structuredContent,omitempty"`
+	IsError           bool        `json:"isError,omitempty"`
 }
 
 // MCP Resource Types
@@ -117,10 +177,44 @@ type Resource struct {
 	MimeType    string `json:"mimeType,omitempty"`
 }
 
-type ResourcesListRequest struct{}
+type ResourcesListRequest struct {
+	Cursor string `json:"cursor,omitempty"`
+}
 
 type ResourcesListResponse struct {
-	Resources []Resource `json:"resources"`
+	Resources  []Resource `json:"resources"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+}
+
+type ResourcesSubscribeRequest struct {
+	URI string `json:"uri"`
+}
+
+type ResourcesUnsubscribeRequest struct {
+	URI string `json:"uri"`
+}
+
+// ResourceUpdatedNotification tells a subscribed client that a resource
+// has changed and should be read again
+type ResourceUpdatedNotification struct {
+	URI string `json:"uri"`
+}
+
+// ResourceTemplate describes a family of resources by RFC 6570 URI template
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+type ResourceTemplatesListRequest struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+type ResourceTemplatesListResponse struct {
+	ResourceTemplates []ResourceTemplate `json:"resourceTemplates"`
+	NextCursor        string             `json:"nextCursor,omitempty"`
 }
 
 type ResourcesReadRequest struct {
@@ -151,10 +245,13 @@ type PromptArgument struct {
 	Required    bool   `json:"required,omitempty"`
 }
 
-type PromptsListRequest struct{}
+type PromptsListRequest struct {
+	Cursor string `json:"cursor,omitempty"`
+}
 
 type PromptsListResponse struct {
-	Prompts []Prompt `json:"prompts"`
+	Prompts    []Prompt `json:"prompts"`
+	NextCursor string   `json:"nextCursor,omitempty"`
 }
 
 type PromptsGetRequest struct {
@@ -167,10 +264,86 @@ type PromptsGetResponse struct {
 	Messages    []Message `json:"messages"`
 }
 
+// Completion Types
+
+// Completion reference types
+const (
+	RefPrompt   = "ref/prompt"
+	RefResource = "ref/resource"
+)
+
+// CompleteReference names what is being completed: a prompt by Name, or a
+// resource template by URI
+type CompleteReference struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+	URI  string `json:"uri,omitempty"`
+}
+
+type CompleteArgument struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// CompleteContext carries the values of arguments already filled in
+type CompleteContext struct {
+	Arguments map[string]string `json:"arguments,omitempty"`
+}
+
+type CompleteRequest struct {
+	Ref      CompleteReference `json:"ref"`
+	Argument CompleteArgument  `json:"argument"`
+	Context  *CompleteContext  `json:"context,omitempty"`
+}
+
+type Completion struct {
+	Values  []string `json:"values"`
+	Total   int      `json:"total,omitempty"`
+	HasMore bool     `json:"hasMore,omitempty"`
+}
+
+type CompleteResponse struct {
+	Completion Completion `json:"completion"`
+}
+
+// Elicitation Types
+
+// ElicitRequest asks the client's user for input. RequestedSchema is a
+// flat object schema with primitive properties.
+type ElicitRequest struct {
+	Message         string                 `json:"message"`
+	RequestedSchema map[string]interface{} `json:"requestedSchema"`
+}
+
+// ElicitResult is the user's answer: Action is "accept", "decline" or
+// "cancel", and Content holds the input when accepted
+type ElicitResult struct {
+	Action  string                 `json:"action"`
+	Content map[string]interface{} `json:"content,omitempty"`
+}
+
+// Elicitation actions
+const (
+	ElicitActionAccept  = "accept"
+	ElicitActionDecline = "decline"
+	ElicitActionCancel  = "cancel"
+)
+
 // Common Content Types
+
+// Content is one item of tool results and prompt messages. Which fields
+// apply depends on Type: "text" uses Text; "image" and "audio" use Data
+// (base64) and MimeType; "resource_link" uses URI, Name, Description and
+// MimeType; "resource" embeds Resource.
 type Content struct {
 	Type        string                 `json:"type"`
 	Text        string                 `json:"text,omitempty"`
+	Data        string                 `json:"data,omitempty"`
+	MimeType    string                 `json:"mimeType,omitempty"`
+	URI         string                 `json:"uri,omitempty"`
+	Name        string                 `json:"name,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Resource    *ResourceContent       `json:"resource,omitempty"`
 	Annotations map[string]interface{} `json:"annotations,omitempty"`
 }
 
@@ -193,6 +366,10 @@ const (
 	LogLevelEmergency LoggingLevel = "emergency"
 )
 
+type SetLevelRequest struct {
+	Level LoggingLevel `json:"level"`
+}
+
 type LoggingMessageNotification struct {
 	Level  LoggingLevel `json:"level"`
 	Data   interface{}  `json:"data,omitempty"`
@@ -0,0 +1,83 @@
+package mcp
+
+import "context"
+
+// SupportedProtocolVersions lists the protocol revisions the server can
+// negotiate, oldest first. Revisions are dates, so later revisions compare
+// greater as strings.
+var SupportedProtocolVersions = []string{
+	ProtocolVersion20241105,
+	ProtocolVersion20250326,
+	ProtocolVersion20250618,
+}
+
+// supportedVersion reports whether version is a negotiable revision
+func supportedVersion(version string) bool {
+	for _, supported := range SupportedProtocolVersions {
+		if version == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateVersion picks the revision to use with a client that requested
+// requested: that revision if supported, otherwise the latest, which the
+// client may then reject by disconnecting
+func negotiateVersion(requested string) string {
+	if supportedVersion(requested) {
+		return requested
+	}
+	return MCPVersion
+}
+
+// setNegotiated records the outcome of initialize for the session
+func (sess *session) setNegotiated(version string, info ClientInfo, capabilities ClientCapabilities) {
+	sess.negotiatedMu.Lock()
+	defer sess.negotiatedMu.Unlock()
+	sess.protocolVersion = version
+	sess.clientInfo = info
+	sess.clientCapabilities = capabilities
+}
+
+// negotiated returns the session's protocol revision and the capabilities
+// its client declared. The revision is empty until initialize succeeds.
+func (sess *session) negotiated() (string, ClientCapabilities) {
+	sess.negotiatedMu.RLock()
+	defer sess.negotiatedMu.RUnlock()
+	return sess.protocolVersion, sess.clientCapabilities
+}
+
+// ClientFromContext returns the name and version the client of the request
+// in ctx gave in initialize. ok is false outside an initialized session.
+func ClientFromContext(ctx context.Context) (info ClientInfo, ok bool) {
+	sess := sessionFromContext(ctx)
+	if sess == nil {
+		return ClientInfo{}, false
+	}
+	sess.negotiatedMu.RLock()
+	defer sess.negotiatedMu.RUnlock()
+	return sess.clientInfo, sess.protocolVersion != ""
+}
+
+// versionAtLeast reports whether the session in ctx negotiated version or a
+// later revision. Without a session every feature is available.
+func versionAtLeast(ctx context.Context, version string) bool {
+	sess := sessionFromContext(ctx)
+	if sess == nil {
+		return true
+	}
+	negotiated, _ := sess.negotiated()
+	return negotiated >= version
+}
+
+// toolForVersion strips the tool fields the negotiated revision lacks
+func toolForVersion(ctx context.Context, tool Tool) Tool {
+	if !versionAtLeast(ctx, ProtocolVersion20250326) {
+		tool.Annotations = nil
+	}
+	if !versionAtLeast(ctx, ProtocolVersion20250618) {
+		tool.OutputSchema = nil
+	}
+	return tool
+}
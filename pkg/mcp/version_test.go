@@ -0,0 +1,184 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestProtocolVersionNegotiation(t *testing.T) {
+	tests := []struct {
+		requested string
+		expected  string
+	}{
+		{ProtocolVersion20241105, ProtocolVersion20241105},
+		{ProtocolVersion20250326, ProtocolVersion20250326},
+		{ProtocolVersion20250618, ProtocolVersion20250618},
+		{"2099-01-01", MCPVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.requested, func(t *testing.T) {
+			client := startTestServer(t, NewServer("test-server", "1.0.0"))
+			response := client.initializeWith(tt.requested, ClientCapabilities{})
+			if response.ProtocolVersion != tt.expected {
+				t.Errorf("Expected protocol version %s, got %s", tt.expected, response.ProtocolVersion)
+			}
+		})
+	}
+}
+
+func TestInitializeRequiresProtocolVersion(t *testing.T) {
+	client := startTestServer(t, NewServer("test-server", "1.0.0"))
+	resp := client.call(1, "initialize", InitializeRequest{ClientInfo: ClientInfo{Name: "test-client"}})
+	if resp.Error == nil || resp.Error.Code != InvalidParams {
+		t.Errorf("Expected InvalidParams, got %+v", resp.Error)
+	}
+}
+
+func TestRequestsRejectedBeforeInitialize(t *testing.T) {
+	client := startTestServer(t, NewServer("test-server", "1.0.0"))
+
+	resp := client.call(1, "tools/list", nil)
+	if resp.Error == nil || resp.Error.Code != InvalidRequest {
+		t.Errorf("Expected InvalidRequest before initialize, got %+v", resp.Error)
+	}
+
+	// ping is allowed at any time
+	resp = client.call(2, "ping", nil)
+	if resp.Error != nil || string(resp.Result) != "{}" {
+		t.Errorf("Expected an empty ping result before initialize, got %+v", resp)
+	}
+
+	client.initialize()
+	resp = client.call(3, "tools/list", nil)
+	if resp.Error != nil {
+		t.Errorf("Expected tools/list to succeed after initialize, got %+v", resp.Error)
+	}
+}
+
+func TestFeaturesGatedByProtocolVersion(t *testing.T) {
+	readOnly := true
+	server := NewServer("test-server", "1.0.0")
+	server.RegisterTool(Tool{
+		Name:         "status",
+		InputSchema:  map[string]interface{}{"type": "object"},
+		OutputSchema: map[string]interface{}{"type": "object"},
+		Annotations:  &ToolAnnotations{ReadOnlyHint: &readOnly},
+	}, func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return map[string]string{"state": "Running"}, nil
+	})
+
+	tests := []struct {
+		version     string
+		annotations bool
+		structured  bool
+	}{
+		{ProtocolVersion20241105, false, false},
+		{ProtocolVersion20250326, true, false},
+		{ProtocolVersion20250618, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			client := startTestServer(t, server)
+			client.initializeWith(tt.version, ClientCapabilities{})
+
+			var list ToolsListResponse
+			json.Unmarshal(client.call(1, "tools/list", nil).Result, &list)
+			if (list.Tools[0].Annotations != nil) != tt.annotations {
+				t.Errorf("Expected annotations %v, got %+v", tt.annotations, list.Tools[0].Annotations)
+			}
+			if (list.Tools[0].OutputSchema != nil) != tt.structured {
+				t.Errorf("Expected output schema %v, got %+v", tt.structured, list.Tools[0].OutputSchema)
+			}
+
+			var result ToolsCallResponse
+			json.Unmarshal(client.call(2, "tools/call", ToolsCallRequest{Name: "status"}).Result, &result)
+			if (result.StructuredContent != nil) != tt.structured {
+				t.Errorf("Expected structured content %v, got %+v", tt.structured, result.StructuredContent)
+			}
+		})
+	}
+}
+
+func TestElicit(t *testing.T) {
+	server := NewServer("test-server", "1.0.0")
+	server.RegisterTool(Tool{Name: "confirm", InputSchema: map[string]interface{}{"type": "object"}},
+		func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			result, err := Elicit(ctx, "Cancel work unit?", map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"confirm": map[string]interface{}{"type": "boolean"}},
+			})
+			if errors.Is(err, ErrElicitationUnsupported) {
+				return "unsupported", nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			return result.Action, nil
+		})
+
+	t.Run("unsupported", func(t *testing.T) {
+		client := startTestServer(t, server)
+		client.initializeWith(ProtocolVersion20250326, ClientCapabilities{Elicitation: &ElicitationCapability{}})
+
+		var result ToolsCallResponse
+		json.Unmarshal(client.call(1, "tools/call", ToolsCallRequest{Name: "confirm"}).Result, &result)
+		if result.Content[0].Text != "unsupported" {
+			t.Errorf("Expected elicitation to be unsupported, got %+v", result)
+		}
+	})
+
+	t.Run("accepted", func(t *testing.T) {
+		client := startTestServer(t, server)
+		client.initializeWith(ProtocolVersion20250618, ClientCapabilities{Elicitation: &ElicitationCapability{}})
+
+		client.send(JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "tools/call", Params: ToolsCallRequest{Name: "confirm"}})
+		request := client.read()
+		if request.Method != "elicitation/create" || request.ID == nil {
+			t.Fatalf("Expected an elicitation/create request, got %+v", request)
+		}
+		var params ElicitRequest
+		json.Unmarshal(request.Params, &params)
+		if params.Message != "Cancel work unit?" {
+			t.Errorf("Unexpected elicitation message: %q", params.Message)
+		}
+
+		client.send(JSONRPCResponse{JSONRPC: "2.0", ID: request.ID, Result: ElicitResult{
+			Action:  ElicitActionAccept,
+			Content: map[string]interface{}{"confirm": true},
+		}})
+		resp := client.read()
+		var result ToolsCallResponse
+		json.Unmarshal(resp.Result, &result)
+		if resp.ID != float64(1) || result.Content[0].Text != ElicitActionAccept {
+			t.Errorf("Expected the tool to see the accepted elicitation, got %+v", resp)
+		}
+	})
+}
+
+func TestClientFromContext(t *testing.T) {
+	server := NewServer("test-server", "1.0.0")
+	server.RegisterTool(Tool{Name: "whoami", InputSchema: map[string]interface{}{"type": "object"}},
+		func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			info, ok := ClientFromContext(ctx)
+			if !ok {
+				return nil, errors.New("no client")
+			}
+			return info, nil
+		})
+
+	if _, ok := ClientFromContext(context.Background()); ok {
+		t.Error("Expected no client outside a session")
+	}
+
+	client := startTestServer(t, server)
+	client.initialize()
+	var result ToolsCallResponse
+	json.Unmarshal(client.call(1, "tools/call", ToolsCallRequest{Name: "whoami"}).Result, &result)
+	if result.IsError || result.Content[0].Text != `{"name":"test-client","version":"1.0.0"}` {
+		t.Errorf("Expected the initializing client, got %+v", result)
+	}
+}
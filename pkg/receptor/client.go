@@ -0,0 +1,370 @@
+package receptor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// controlGreeting prefixes the banner sent by the control service on connect
+const controlGreeting = "Receptor Control"
+
+var workCreatedPattern = regexp.MustCompile(`^Work unit created with ID (.+)\. Send stdin data and EOF\.`)
+
+// ControlError is an error reported by the Receptor control service itself,
+// as opposed to a failure to reach it
+type ControlError struct {
+	Message string
+}
+
+func (e *ControlError) Error() string {
+	return "receptor: " + e.Message
+}
+
+// Dialer opens a raw connection to a Receptor control service
+type Dialer interface {
+	DialContext(ctx context.Context) (net.Conn, error)
+}
+
+// UnixDialer connects to a control service Unix socket
+type UnixDialer struct {
+	Path string
+}
+
+// DialContext implements Dialer
+func (d UnixDialer) DialContext(ctx context.Context) (net.Conn, error) {
+	var nd net.Dialer
+	return nd.DialContext(ctx, "unix", d.Path)
+}
+
+// Client speaks the receptorctl line protocol to a single control service.
+// Each command uses its own connection, mirroring receptorctl.
+type Client struct {
+	dialer  Dialer
+	timeout time.Duration
+	logger  Logger
+}
+
+// NewClient creates a client that dials the control service with dialer.
+// A non-zero timeout bounds every command that does not stream results.
+func NewClient(dialer Dialer, timeout time.Duration) *Client {
+	return &Client{
+		dialer:  dialer,
+		timeout: timeout,
+		logger:  nopLogger{},
+	}
+}
+
+// SetLogger sets the logger for work commands. It must be called before
+// the client is used.
+func (c *Client) SetLogger(logger Logger) {
+	c.logger = logger
+}
+
+// controlConn is an established, greeted control service connection
+type controlConn struct {
+	net.Conn
+	reader *bufio.Reader
+	node   string
+	stop   func() bool
+}
+
+// Close stops watching the context and closes the connection
+func (c *controlConn) Close() error {
+	c.stop()
+	return c.Conn.Close()
+}
+
+// readLine reads one response line, converting "ERROR:" replies to ControlError
+func (c *controlConn) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", fmt.Errorf("reading control response: %w", err)
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if msg, ok := strings.CutPrefix(line, "ERROR:"); ok {
+		return "", &ControlError{Message: strings.TrimSpace(msg)}
+	}
+	return line, nil
+}
+
+// closeWrite half-closes the connection to signal end of stdin
+func (c *controlConn) closeWrite() error {
+	if cw, ok := c.Conn.(interface{ CloseWrite() error }); ok {
+		return cw.CloseWrite()
+	}
+	return fmt.Errorf("connection type %T cannot half-close", c.Conn)
+}
+
+// connect dials the control service and consumes its greeting. The
+// connection is closed when ctx is done, which aborts any blocked read.
+func (c *Client) connect(ctx context.Context) (*controlConn, error) {
+	conn, err := c.dialer.DialContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to receptor control service: %w", err)
+	}
+
+	cc := &controlConn{
+		Conn:   conn,
+		reader: bufio.NewReader(conn),
+		stop:   context.AfterFunc(ctx, func() { conn.Close() }),
+	}
+
+	greeting, err := cc.readLine()
+	if err != nil {
+		cc.Close()
+		return nil, contextError(ctx, err)
+	}
+	if !strings.HasPrefix(greeting, controlGreeting) {
+		cc.Close()
+		return nil, fmt.Errorf("unexpected control service greeting: %q", greeting)
+	}
+	cc.node = strings.TrimSpace(strings.TrimPrefix(greeting, controlGreeting+", node"))
+	return cc, nil
+}
+
+// send writes a command line; map commands are JSON encoded
+func (cc *controlConn) send(command interface{}) error {
+	var line []byte
+	switch cmd := command.(type) {
+	case string:
+		line = []byte(cmd)
+	default:
+		data, err := json.Marshal(cmd)
+		if err != nil {
+			return fmt.Errorf("encoding control command: %w", err)
+		}
+		line = data
+	}
+	line = append(line, '\n')
+	if _, err := cc.Write(line); err != nil {
+		return fmt.Errorf("sending control command: %w", err)
+	}
+	return nil
+}
+
+// withTimeout applies the client's command timeout to ctx
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// simpleCommand sends a command and decodes its single-line JSON reply
+func (c *Client) simpleCommand(ctx context.Context, command interface{}, out interface{}) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	cc, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer cc.Close()
+
+	if err := cc.send(command); err != nil {
+		return err
+	}
+	line, err := cc.readLine()
+	if err != nil {
+		return contextError(ctx, err)
+	}
+	if err := json.Unmarshal([]byte(line), out); err != nil {
+		return fmt.Errorf("decoding control response %q: %w", line, err)
+	}
+	return nil
+}
+
+// contextError prefers the context's error when a read failed because the
+// context closed the connection
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// workCommand builds a JSON "work" command
+func workCommand(subcommand string, fields map[string]interface{}) map[string]interface{} {
+	cmd := map[string]interface{}{
+		"command":    "work",
+		"subcommand": subcommand,
+	}
+	for k, v := range fields {
+		cmd[k] = v
+	}
+	return cmd
+}
+
+// Status returns the status of the node the control service runs on
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	var status Status
+	if err := c.simpleCommand(ctx, "status", &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// SubmitWork creates a work unit, streams the payload to its stdin and
+// returns the new unit ID
+func (c *Client) SubmitWork(ctx context.Context, req WorkRequest) (string, error) {
+	if req.WorkType == "" {
+		return "", errors.New("work type is required")
+	}
+
+	fields := map[string]interface{}{
+		"worktype": req.WorkType,
+	}
+	node := req.Node
+	if node == "" {
+		node = "localhost"
+	}
+	fields["node"] = node
+	if req.SignWork {
+		fields["signwork"] = "true"
+	}
+	if req.Signature != "" {
+		fields["signature"] = req.Signature
+	}
+	for k, v := range req.Params {
+		if _, reserved := fields[k]; reserved || k == "command" || k == "subcommand" {
+			return "", fmt.Errorf("parameter %q conflicts with a submit field", k)
+		}
+		fields[k] = v
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	cc, err := c.connect(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer cc.Close()
+
+	if err := cc.send(workCommand("submit", fields)); err != nil {
+		return "", err
+	}
+	line, err := cc.readLine()
+	if err != nil {
+		return "", contextError(ctx, fmt.Errorf("starting work unit: %w", err))
+	}
+	if !workCreatedPattern.MatchString(line) {
+		return "", fmt.Errorf("starting work unit: unexpected reply %q", line)
+	}
+
+	if _, err := cc.Write(req.Payload); err != nil {
+		return "", contextError(ctx, fmt.Errorf("sending work payload: %w", err))
+	}
+	if err := cc.closeWrite(); err != nil {
+		return "", err
+	}
+
+	line, err = cc.readLine()
+	if err != nil {
+		return "", contextError(ctx, err)
+	}
+	var result struct {
+		Result string `json:"result"`
+		UnitID string `json:"unitid"`
+	}
+	if err := json.Unmarshal([]byte(line), &result); err != nil {
+		return "", fmt.Errorf("decoding submit response %q: %w", line, err)
+	}
+	c.logger.Infof("Submitted %s work unit %s to node %s (%d byte payload)", req.WorkType, result.UnitID, node, len(req.Payload))
+	return result.UnitID, nil
+}
+
+// ListWork returns the status of every work unit known to the node, keyed
+// by unit ID
+func (c *Client) ListWork(ctx context.Context) (map[string]WorkStatus, error) {
+	units := map[string]WorkStatus{}
+	if err := c.simpleCommand(ctx, workCommand("list", nil), &units); err != nil {
+		return nil, err
+	}
+	return units, nil
+}
+
+// WorkStatus returns the status of a single work unit
+func (c *Client) WorkStatus(ctx context.Context, unitID string) (*WorkStatus, error) {
+	var status WorkStatus
+	cmd := workCommand("status", map[string]interface{}{"unitid": unitID})
+	if err := c.simpleCommand(ctx, cmd, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// CancelWork cancels a pending or running work unit
+func (c *Client) CancelWork(ctx context.Context, unitID string) error {
+	var reply map[string]interface{}
+	cmd := workCommand("cancel", map[string]interface{}{"unitid": unitID})
+	if err := c.simpleCommand(ctx, cmd, &reply); err != nil {
+		return err
+	}
+	c.logger.Infof("Cancelled work unit %s", unitID)
+	return nil
+}
+
+// ReleaseWork cancels a work unit if needed and deletes its files
+func (c *Client) ReleaseWork(ctx context.Context, unitID string) error {
+	var reply map[string]interface{}
+	cmd := workCommand("release", map[string]interface{}{"unitid": unitID})
+	if err := c.simpleCommand(ctx, cmd, &reply); err != nil {
+		return err
+	}
+	c.logger.Infof("Released work unit %s", unitID)
+	return nil
+}
+
+// WorkResults streams a unit's stdout starting at byte offset startPos. The
+// control service keeps the stream open until the unit finishes, so the
+// command timeout does not apply; cancel ctx to abandon the read. The
+// caller must close the returned reader.
+func (c *Client) WorkResults(ctx context.Context, unitID string, startPos int64) (io.ReadCloser, error) {
+	cc, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := workCommand("results", map[string]interface{}{
+		"unitid":   unitID,
+		"startpos": startPos,
+	})
+	if err := cc.send(cmd); err != nil {
+		cc.Close()
+		return nil, err
+	}
+	line, err := cc.readLine()
+	if err != nil {
+		cc.Close()
+		return nil, contextError(ctx, err)
+	}
+	if !strings.HasPrefix(line, "Streaming results for work unit") {
+		cc.Close()
+		return nil, fmt.Errorf("reading work results: unexpected reply %q", line)
+	}
+	c.logger.Debugf("Streaming results of work unit %s from byte %d", unitID, startPos)
+
+	return &resultsReader{conn: cc}, nil
+}
+
+// resultsReader reads a results stream until the control service closes it
+type resultsReader struct {
+	conn *controlConn
+}
+
+func (r *resultsReader) Read(p []byte) (int, error) {
+	return r.conn.reader.Read(p)
+}
+
+func (r *resultsReader) Close() error {
+	return r.conn.Close()
+}
@@ -0,0 +1,426 @@
+package receptor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeControl is an in-process stand-in for a Receptor control service
+type fakeControl struct {
+	t        *testing.T
+	listener net.Listener
+	node     string
+
+	mu       sync.Mutex
+	commands []map[string]interface{}
+	units    map[string]WorkStatus
+	stdin    map[string]string
+	results  map[string]string
+	status   Status
+	// follow holds results streams open, as for a unit still running
+	follow bool
+}
+
+// newFakeControl starts a fake control service on a Unix socket
+func newFakeControl(t *testing.T, node string) *fakeControl {
+	t.Helper()
+	listener, err := net.Listen("unix", filepath.Join(t.TempDir(), "control.sock"))
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	return startFakeControl(t, listener, node)
+}
+
+// startFakeControl serves the fake control protocol on listener
+func startFakeControl(t *testing.T, listener net.Listener, node string) *fakeControl {
+	f := &fakeControl{
+		t:        t,
+		listener: listener,
+		node:     node,
+		units:    map[string]WorkStatus{},
+		stdin:    map[string]string{},
+		results:  map[string]string{},
+		status: Status{
+			NodeID:       node,
+			RoutingTable: map[string]string{},
+		},
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeControl) dialer() Dialer {
+	return UnixDialer{Path: f.listener.Addr().String()}
+}
+
+func (f *fakeControl) client() *Client {
+	return NewClient(f.dialer(), 5*time.Second)
+}
+
+// lastCommand returns the most recent command the fake received
+func (f *fakeControl) lastCommand() map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.commands) == 0 {
+		return nil
+	}
+	return f.commands[len(f.commands)-1]
+}
+
+func (f *fakeControl) serve(conn net.Conn) {
+	defer conn.Close()
+	io.WriteString(conn, "Receptor Control, node "+f.node+"\n")
+
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return
+	}
+	line = strings.TrimSpace(line)
+
+	cmd := map[string]interface{}{}
+	if strings.HasPrefix(line, "{") {
+		json.Unmarshal([]byte(line), &cmd)
+	} else {
+		cmd["command"] = line
+	}
+	f.mu.Lock()
+	f.commands = append(f.commands, cmd)
+	f.mu.Unlock()
+
+	reply := func(v interface{}) {
+		data, _ := json.Marshal(v)
+		conn.Write(append(data, '\n'))
+	}
+
+	unitID, _ := cmd["unitid"].(string)
+	f.mu.Lock()
+	_, known := f.units[unitID]
+	f.mu.Unlock()
+
+	switch {
+	case cmd["command"] == "status":
+		f.mu.Lock()
+		status := f.status
+		f.mu.Unlock()
+		reply(status)
+	case cmd["subcommand"] == "submit":
+		f.mu.Lock()
+		id := "unit" + string(rune('A'+len(f.units)))
+		f.mu.Unlock()
+		io.WriteString(conn, "Work unit created with ID "+id+". Send stdin data and EOF.\n")
+		payload, _ := io.ReadAll(reader)
+		f.mu.Lock()
+		f.units[id] = WorkStatus{State: WorkStatePending, WorkType: cmd["worktype"].(string)}
+		f.stdin[id] = string(payload)
+		f.mu.Unlock()
+		reply(map[string]string{"result": "Job Started", "unitid": id})
+	case cmd["subcommand"] == "list":
+		f.mu.Lock()
+		units := f.units
+		reply(units)
+		f.mu.Unlock()
+	case !known:
+		io.WriteString(conn, "ERROR: unknown work unit "+unitID+"\n")
+	case cmd["subcommand"] == "status":
+		f.mu.Lock()
+		status := f.units[unitID]
+		f.mu.Unlock()
+		reply(status)
+	case cmd["subcommand"] == "cancel":
+		f.mu.Lock()
+		status := f.units[unitID]
+		status.State = WorkStateCanceled
+		f.units[unitID] = status
+		f.mu.Unlock()
+		reply(map[string]string{"cancelled": unitID})
+	case cmd["subcommand"] == "release":
+		f.mu.Lock()
+		delete(f.units, unitID)
+		f.mu.Unlock()
+		reply(map[string]string{"released": unitID})
+	case cmd["subcommand"] == "results":
+		f.mu.Lock()
+		results := f.results[unitID]
+		follow := f.follow
+		f.mu.Unlock()
+		start := int(cmd["startpos"].(float64))
+		io.WriteString(conn, "Streaming results for work unit "+unitID+"\n")
+		if start < len(results) {
+			io.WriteString(conn, results[start:])
+		}
+		if follow {
+			// Wait for the client to hang up
+			io.Copy(io.Discard, conn)
+		}
+	default:
+		io.WriteString(conn, "ERROR: unknown command\n")
+	}
+}
+
+func TestClientStatus(t *testing.T) {
+	fake := newFakeControl(t, "controller")
+	fake.status.Connections = []Connection{{NodeID: "worker-01", Cost: 1}}
+	fake.status.RoutingTable = map[string]string{"worker-01": "worker-01"}
+	fake.status.Advertisements = []Advertisement{{
+		NodeID:       "worker-01",
+		Service:      "control",
+		WorkCommands: []WorkCommand{{WorkType: "echo"}},
+	}}
+
+	status, err := fake.client().Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status returned error: %v", err)
+	}
+
+	if status.NodeID != "controller" {
+		t.Errorf("Expected node ID 'controller', got '%s'", status.NodeID)
+	}
+
+	if len(status.Connections) != 1 || status.Connections[0].NodeID != "worker-01" {
+		t.Errorf("Unexpected connections: %+v", status.Connections)
+	}
+
+	nodes := status.Nodes()
+	if len(nodes) != 2 {
+		t.Errorf("Expected 2 nodes, got %v", nodes)
+	}
+}
+
+func TestClientSubmitWork(t *testing.T) {
+	fake := newFakeControl(t, "controller")
+	client := fake.client()
+
+	unitID, err := client.SubmitWork(context.Background(), WorkRequest{
+		Node:     "worker-01",
+		WorkType: "echo",
+		Payload:  []byte("hello"),
+		Params:   map[string]string{"params": "--verbose"},
+	})
+	if err != nil {
+		t.Fatalf("SubmitWork returned error: %v", err)
+	}
+
+	if unitID != "unitA" {
+		t.Errorf("Expected unit ID 'unitA', got '%s'", unitID)
+	}
+
+	cmd := fake.lastCommand()
+	if cmd["node"] != "worker-01" || cmd["worktype"] != "echo" || cmd["params"] != "--verbose" {
+		t.Errorf("Unexpected submit command: %v", cmd)
+	}
+
+	if fake.stdin[unitID] != "hello" {
+		t.Errorf("Expected payload 'hello', got '%s'", fake.stdin[unitID])
+	}
+}
+
+func TestClientSubmitSignedWork(t *testing.T) {
+	fake := newFakeControl(t, "controller")
+
+	_, err := fake.client().SubmitWork(context.Background(), WorkRequest{
+		Node:      "worker-01",
+		WorkType:  "security-audit",
+		SignWork:  true,
+		Signature: "header.claims.signature",
+	})
+	if err != nil {
+		t.Fatalf("SubmitWork returned error: %v", err)
+	}
+
+	cmd := fake.lastCommand()
+	if cmd["signwork"] != "true" || cmd["signature"] != "header.claims.signature" {
+		t.Errorf("Expected signwork and signature in the submit command, got %v", cmd)
+	}
+
+	// Unsigned work carries neither
+	fake.client().SubmitWork(context.Background(), WorkRequest{Node: "worker-01", WorkType: "echo"})
+	if cmd := fake.lastCommand(); cmd["signwork"] != nil || cmd["signature"] != nil {
+		t.Errorf("Expected no signing fields for unsigned work, got %v", cmd)
+	}
+}
+
+func TestClientSubmitWorkRejectsReservedParams(t *testing.T) {
+	fake := newFakeControl(t, "controller")
+
+	_, err := fake.client().SubmitWork(context.Background(), WorkRequest{
+		WorkType: "echo",
+		Params:   map[string]string{"node": "elsewhere"},
+	})
+	if err == nil {
+		t.Fatal("Expected error for reserved parameter")
+	}
+}
+
+func TestClientWorkLifecycle(t *testing.T) {
+	fake := newFakeControl(t, "controller")
+	client := fake.client()
+	ctx := context.Background()
+
+	unitID, err := client.SubmitWork(ctx, WorkRequest{WorkType: "echo"})
+	if err != nil {
+		t.Fatalf("SubmitWork returned error: %v", err)
+	}
+
+	units, err := client.ListWork(ctx)
+	if err != nil {
+		t.Fatalf("ListWork returned error: %v", err)
+	}
+	if _, exists := units[unitID]; !exists {
+		t.Errorf("Expected %s in work list, got %v", unitID, units)
+	}
+
+	if err := client.CancelWork(ctx, unitID); err != nil {
+		t.Fatalf("CancelWork returned error: %v", err)
+	}
+
+	status, err := client.WorkStatus(ctx, unitID)
+	if err != nil {
+		t.Fatalf("WorkStatus returned error: %v", err)
+	}
+	if status.State != WorkStateCanceled {
+		t.Errorf("Expected state Canceled, got %s", status.State)
+	}
+
+	if err := client.ReleaseWork(ctx, unitID); err != nil {
+		t.Fatalf("ReleaseWork returned error: %v", err)
+	}
+
+	_, err = client.WorkStatus(ctx, unitID)
+	var controlErr *ControlError
+	if !errors.As(err, &controlErr) {
+		t.Fatalf("Expected ControlError for released unit, got %v", err)
+	}
+	if !strings.Contains(controlErr.Message, "unknown work unit") {
+		t.Errorf("Unexpected error message: %s", controlErr.Message)
+	}
+}
+
+func TestClientWorkResults(t *testing.T) {
+	fake := newFakeControl(t, "controller")
+	fake.units["unitA"] = WorkStatus{State: WorkStateSucceeded}
+	fake.results["unitA"] = "line one\nline two\n"
+
+	reader, err := fake.client().WorkResults(context.Background(), "unitA", 5)
+	if err != nil {
+		t.Fatalf("WorkResults returned error: %v", err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Reading results failed: %v", err)
+	}
+
+	if string(data) != "one\nline two\n" {
+		t.Errorf("Unexpected results: %q", data)
+	}
+}
+
+func TestClientWorkResultsCancel(t *testing.T) {
+	fake := newFakeControl(t, "controller")
+	fake.units["unitA"] = WorkStatus{State: WorkStateRunning}
+	fake.results["unitA"] = "partial"
+	fake.follow = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reader, err := fake.client().WorkResults(ctx, "unitA", 0)
+	if err != nil {
+		t.Fatalf("WorkResults returned error: %v", err)
+	}
+	defer reader.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.ReadAll(reader)
+		done <- err
+	}()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Cancelling the context did not abort the results stream")
+	}
+}
+
+func TestClientConnectError(t *testing.T) {
+	client := NewClient(UnixDialer{Path: filepath.Join(t.TempDir(), "missing.sock")}, time.Second)
+
+	if _, err := client.Status(context.Background()); err == nil {
+		t.Fatal("Expected error connecting to missing socket")
+	}
+}
+
+func TestClientConnectDeadline(t *testing.T) {
+	// A control service that accepts connections but never greets
+	listener, err := net.Listen("unix", filepath.Join(t.TempDir(), "control.sock"))
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go io.Copy(io.Discard, conn)
+		}
+	}()
+
+	client := NewClient(UnixDialer{Path: listener.Addr().String()}, 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.WorkStatus(ctx, "unitA"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded waiting for the greeting, got %v", err)
+	}
+}
+
+func TestWorkStateFinal(t *testing.T) {
+	if WorkStateRunning.Final() {
+		t.Error("Running should not be final")
+	}
+
+	if !WorkStateSucceeded.Final() || !WorkStateFailed.Final() || !WorkStateCanceled.Final() {
+		t.Error("Succeeded, Failed and Canceled should be final")
+	}
+
+	if WorkStateSucceeded.String() != "Succeeded" {
+		t.Errorf("Expected 'Succeeded', got '%s'", WorkStateSucceeded.String())
+	}
+}
+
+func TestWorkStatusRemote(t *testing.T) {
+	local := WorkStatus{WorkType: "echo"}
+	if local.Remote() != nil {
+		t.Error("Expected a local unit to have no remote details")
+	}
+
+	remote := WorkStatus{
+		WorkType:  "remote",
+		ExtraData: json.RawMessage(`{"RemoteNode":"worker-01","RemoteWorkType":"echo","RemoteUnitID":"xyz","TLSClient":""}`),
+	}
+	if got := remote.Remote(); got == nil || got.RemoteNode != "worker-01" || got.RemoteWorkType != "echo" {
+		t.Errorf("Expected worker-01 running echo, got %+v", got)
+	}
+}
@@ -0,0 +1,18 @@
+package receptor
+
+// Logger receives diagnostic messages from clients and pools. The MCP
+// server forwards them to stderr and to interested MCP clients.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warningf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// nopLogger discards messages; it is the default until SetLogger is called
+type nopLogger struct{}
+
+func (nopLogger) Debugf(format string, args ...interface{})   {}
+func (nopLogger) Infof(format string, args ...interface{})    {}
+func (nopLogger) Warningf(format string, args ...interface{}) {}
+func (nopLogger) Errorf(format string, args ...interface{})   {}
@@ -0,0 +1,325 @@
+package receptor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Backoff bounds for reconnecting to an unhealthy entry point
+const (
+	minReconnectBackoff = time.Second
+	maxReconnectBackoff = time.Minute
+)
+
+// ErrNoHealthyEndpoints is returned when no configured entry point is reachable
+var ErrNoHealthyEndpoints = errors.New("no healthy Receptor control service")
+
+// Endpoint is a configured control service entry point into the mesh
+type Endpoint struct {
+	// Name identifies the entry point in output and logs, e.g. the
+	// socket path or host:port it was configured with
+	Name   string
+	Dialer Dialer
+}
+
+// EndpointState is a snapshot of an entry point's health
+type EndpointState struct {
+	Name      string
+	Healthy   bool
+	LastError error
+	// Status is the most recent successful status, which may be stale
+	// when the entry point is unhealthy
+	Status    *Status
+	CheckedAt time.Time
+}
+
+// member is a pooled entry point and its health tracking state
+type member struct {
+	name   string
+	client *Client
+
+	mu          sync.Mutex
+	healthy     bool
+	checked     bool
+	status      *Status
+	lastErr     error
+	checkedAt   time.Time
+	backoff     time.Duration
+	nextAttempt time.Time
+}
+
+// Pool keeps a client per configured entry point, health-checks them and
+// routes commands to an entry point that can reach the target node
+type Pool struct {
+	members  []*member
+	interval time.Duration
+	logger   Logger
+}
+
+// NewPool creates a pool over endpoints. Entry points are health-checked
+// every interval; unhealthy ones are retried with exponential backoff.
+func NewPool(endpoints []Endpoint, timeout, interval time.Duration) (*Pool, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("at least one Receptor endpoint is required")
+	}
+
+	pool := &Pool{interval: interval, logger: nopLogger{}}
+	for _, ep := range endpoints {
+		pool.members = append(pool.members, &member{
+			name:   ep.Name,
+			client: NewClient(ep.Dialer, timeout),
+		})
+	}
+	return pool, nil
+}
+
+// SetLogger sets the logger for entry point health changes. It must be
+// called before the pool is used.
+func (p *Pool) SetLogger(logger Logger) {
+	p.logger = logger
+}
+
+// SetWorkLogger sets the logger for the work commands of every entry
+// point's client. It must be called before the pool is used.
+func (p *Pool) SetWorkLogger(logger Logger) {
+	for _, m := range p.members {
+		m.client.SetLogger(logger)
+	}
+}
+
+// Run health-checks entry points until ctx is done
+func (p *Pool) Run(ctx context.Context) {
+	p.Check(ctx)
+
+	// Wake often enough to honor reconnect backoff deadlines
+	tick := minReconnectBackoff
+	if p.interval > 0 && p.interval < tick {
+		tick = p.interval
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.checkDue(ctx)
+		}
+	}
+}
+
+// Check health-checks every entry point now
+func (p *Pool) Check(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, m := range p.members {
+		wg.Add(1)
+		go func(m *member) {
+			defer wg.Done()
+			p.check(ctx, m)
+		}(m)
+	}
+	wg.Wait()
+}
+
+// checkDue health-checks entry points whose interval or backoff has elapsed
+func (p *Pool) checkDue(ctx context.Context) {
+	now := time.Now()
+	var wg sync.WaitGroup
+	for _, m := range p.members {
+		m.mu.Lock()
+		due := !now.Before(m.nextAttempt)
+		m.mu.Unlock()
+		if !due {
+			continue
+		}
+		wg.Add(1)
+		go func(m *member) {
+			defer wg.Done()
+			p.check(ctx, m)
+		}(m)
+	}
+	wg.Wait()
+}
+
+// check fetches an entry point's status and updates its health
+func (p *Pool) check(ctx context.Context, m *member) {
+	status, err := m.client.Status(ctx)
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	wasHealthy, wasChecked := m.healthy, m.checked
+	m.checked = true
+	m.checkedAt = time.Now()
+	m.lastErr = err
+	if err != nil {
+		if wasHealthy || !wasChecked {
+			p.logger.Warningf("Receptor control service %s is unhealthy: %v", m.name, err)
+		}
+		m.healthy = false
+		if m.backoff == 0 {
+			m.backoff = minReconnectBackoff
+		} else {
+			m.backoff = min(m.backoff*2, maxReconnectBackoff)
+		}
+		m.nextAttempt = m.checkedAt.Add(m.backoff)
+		return
+	}
+
+	if !wasHealthy {
+		p.logger.Infof("Receptor control service %s is healthy (node %s)", m.name, status.NodeID)
+	}
+	m.healthy = true
+	m.status = status
+	m.backoff = 0
+	m.nextAttempt = m.checkedAt.Add(p.interval)
+}
+
+// States returns a snapshot of every entry point in configuration order
+func (p *Pool) States() []EndpointState {
+	states := make([]EndpointState, 0, len(p.members))
+	for _, m := range p.members {
+		m.mu.Lock()
+		states = append(states, EndpointState{
+			Name:      m.name,
+			Healthy:   m.healthy,
+			LastError: m.lastErr,
+			Status:    m.status,
+			CheckedAt: m.checkedAt,
+		})
+		m.mu.Unlock()
+	}
+	return states
+}
+
+// healthyMembers returns healthy entry points in configuration order. If
+// nothing has been checked yet, every entry point is checked first.
+func (p *Pool) healthyMembers(ctx context.Context) []*member {
+	unchecked := false
+	for _, m := range p.members {
+		m.mu.Lock()
+		unchecked = unchecked || !m.checked
+		m.mu.Unlock()
+	}
+	if unchecked {
+		p.Check(ctx)
+	}
+
+	var healthy []*member
+	for _, m := range p.members {
+		m.mu.Lock()
+		if m.healthy {
+			healthy = append(healthy, m)
+		}
+		m.mu.Unlock()
+	}
+	return healthy
+}
+
+// Client returns the client of the first healthy entry point
+func (p *Pool) Client(ctx context.Context) (*Client, error) {
+	healthy := p.healthyMembers(ctx)
+	if len(healthy) == 0 {
+		return nil, p.unhealthyError()
+	}
+	return healthy[0].client, nil
+}
+
+// ClientFor returns the client of a healthy entry point that can reach
+// node: the node's own control service if configured, otherwise the first
+// entry point with a route to it. The node's status is refreshed once if
+// no entry point knows it yet.
+func (p *Pool) ClientFor(ctx context.Context, node string) (*Client, error) {
+	if m := p.route(ctx, node); m != nil {
+		return m.client, nil
+	}
+
+	p.Check(ctx)
+	if m := p.route(ctx, node); m != nil {
+		return m.client, nil
+	}
+
+	if len(p.healthyMembers(ctx)) == 0 {
+		return nil, p.unhealthyError()
+	}
+	return nil, fmt.Errorf("no Receptor control service can reach node %s", node)
+}
+
+// route picks the entry point for node from the last known statuses
+func (p *Pool) route(ctx context.Context, node string) *member {
+	var routed *member
+	for _, m := range p.healthyMembers(ctx) {
+		m.mu.Lock()
+		status := m.status
+		m.mu.Unlock()
+		if status.NodeID == node {
+			return m
+		}
+		if routed == nil && status.RoutingTable[node] != "" {
+			routed = m
+		}
+	}
+	return routed
+}
+
+// FindWork locates the entry point that owns a work unit. Unit IDs are local
+// to the control service that created them, so each healthy entry point is
+// asked in turn.
+func (p *Pool) FindWork(ctx context.Context, unitID string) (*Client, *WorkStatus, error) {
+	healthy := p.healthyMembers(ctx)
+	if len(healthy) == 0 {
+		return nil, nil, p.unhealthyError()
+	}
+
+	var lastErr error
+	for _, m := range healthy {
+		status, err := m.client.WorkStatus(ctx, unitID)
+		if err == nil {
+			return m.client, status, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+	}
+	return nil, nil, lastErr
+}
+
+// ListWork merges the work units of every healthy entry point
+func (p *Pool) ListWork(ctx context.Context) (map[string]WorkStatus, error) {
+	healthy := p.healthyMembers(ctx)
+	if len(healthy) == 0 {
+		return nil, p.unhealthyError()
+	}
+
+	units := map[string]WorkStatus{}
+	for _, m := range healthy {
+		memberUnits, err := m.client.ListWork(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing work on %s: %w", m.name, err)
+		}
+		for id, status := range memberUnits {
+			units[id] = status
+		}
+	}
+	return units, nil
+}
+
+// unhealthyError describes why no entry point is usable
+func (p *Pool) unhealthyError() error {
+	for _, m := range p.members {
+		m.mu.Lock()
+		err := m.lastErr
+		m.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrNoHealthyEndpoints, m.name, err)
+		}
+	}
+	return ErrNoHealthyEndpoints
+}
@@ -0,0 +1,213 @@
+package receptor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPoolClientFor(t *testing.T) {
+	controller := newFakeControl(t, "controller")
+	controller.status.RoutingTable = map[string]string{"worker-01": "worker-01"}
+	edge := newFakeControl(t, "edge")
+	edge.status.RoutingTable = map[string]string{"edge-worker": "edge-worker"}
+
+	pool, err := NewPool([]Endpoint{
+		{Name: "controller", Dialer: controller.dialer()},
+		{Name: "edge", Dialer: edge.dialer()},
+	}, time.Second, time.Minute)
+	if err != nil {
+		t.Fatalf("NewPool returned error: %v", err)
+	}
+	ctx := context.Background()
+
+	// A node reachable only from the edge entry point routes there
+	client, err := pool.ClientFor(ctx, "edge-worker")
+	if err != nil {
+		t.Fatalf("ClientFor returned error: %v", err)
+	}
+	if _, err := client.SubmitWork(ctx, WorkRequest{Node: "edge-worker", WorkType: "echo"}); err != nil {
+		t.Fatalf("SubmitWork returned error: %v", err)
+	}
+	if edge.lastCommand()["node"] != "edge-worker" {
+		t.Errorf("Expected submit to go through the edge entry point, got %v", edge.lastCommand())
+	}
+
+	// An entry point's own node routes to it directly
+	client, err = pool.ClientFor(ctx, "controller")
+	if err != nil {
+		t.Fatalf("ClientFor returned error: %v", err)
+	}
+	status, _ := client.Status(ctx)
+	if status.NodeID != "controller" {
+		t.Errorf("Expected controller client, got node %s", status.NodeID)
+	}
+
+	if _, err := pool.ClientFor(ctx, "unknown"); err == nil {
+		t.Error("Expected error for unreachable node")
+	}
+}
+
+func TestPoolSkipsUnhealthyEndpoints(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.sock")
+	healthy := newFakeControl(t, "controller")
+
+	pool, _ := NewPool([]Endpoint{
+		{Name: "missing", Dialer: UnixDialer{Path: missing}},
+		{Name: "controller", Dialer: healthy.dialer()},
+	}, time.Second, time.Minute)
+
+	client, err := pool.Client(context.Background())
+	if err != nil {
+		t.Fatalf("Client returned error: %v", err)
+	}
+	status, _ := client.Status(context.Background())
+	if status.NodeID != "controller" {
+		t.Errorf("Expected healthy controller client, got node %s", status.NodeID)
+	}
+
+	states := pool.States()
+	if states[0].Healthy || states[0].LastError == nil {
+		t.Errorf("Expected missing endpoint to be unhealthy, got %+v", states[0])
+	}
+	if !states[1].Healthy {
+		t.Errorf("Expected controller endpoint to be healthy, got %+v", states[1])
+	}
+}
+
+func TestPoolReconnectBackoff(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "control.sock")
+	pool, _ := NewPool([]Endpoint{{Name: "controller", Dialer: UnixDialer{Path: socket}}}, time.Second, time.Minute)
+	ctx := context.Background()
+
+	_, err := pool.Client(ctx)
+	if !errors.Is(err, ErrNoHealthyEndpoints) {
+		t.Fatalf("Expected ErrNoHealthyEndpoints, got %v", err)
+	}
+
+	pool.Check(ctx)
+	m := pool.members[0]
+	if m.backoff != 2*minReconnectBackoff {
+		t.Errorf("Expected backoff to double to %v, got %v", 2*minReconnectBackoff, m.backoff)
+	}
+
+	// Once the control service comes up the next check reconnects
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	startFakeControl(t, listener, "controller")
+
+	pool.Check(ctx)
+	if _, err := pool.Client(ctx); err != nil {
+		t.Fatalf("Expected reconnect, got %v", err)
+	}
+	if m.backoff != 0 {
+		t.Errorf("Expected backoff reset after reconnect, got %v", m.backoff)
+	}
+}
+
+func TestPoolFindWork(t *testing.T) {
+	first := newFakeControl(t, "controller")
+	second := newFakeControl(t, "edge")
+	second.units["unitZ"] = WorkStatus{State: WorkStateRunning, WorkType: "echo"}
+
+	pool, _ := NewPool([]Endpoint{
+		{Name: "controller", Dialer: first.dialer()},
+		{Name: "edge", Dialer: second.dialer()},
+	}, time.Second, time.Minute)
+	ctx := context.Background()
+
+	client, status, err := pool.FindWork(ctx, "unitZ")
+	if err != nil {
+		t.Fatalf("FindWork returned error: %v", err)
+	}
+	if status.State != WorkStateRunning {
+		t.Errorf("Expected Running, got %s", status.State)
+	}
+	if err := client.CancelWork(ctx, "unitZ"); err != nil {
+		t.Fatalf("CancelWork through found client returned error: %v", err)
+	}
+
+	units, err := pool.ListWork(ctx)
+	if err != nil {
+		t.Fatalf("ListWork returned error: %v", err)
+	}
+	if _, exists := units["unitZ"]; !exists {
+		t.Errorf("Expected merged work list to include unitZ, got %v", units)
+	}
+
+	if _, _, err := pool.FindWork(ctx, "missing"); err == nil {
+		t.Error("Expected error for unknown unit")
+	}
+}
+
+// recordingLogger keeps the messages logged to it
+type recordingLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *recordingLogger) record(level, format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, level+": "+fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Debugf(format string, args ...interface{}) {
+	l.record("debug", format, args...)
+}
+
+func (l *recordingLogger) Infof(format string, args ...interface{}) {
+	l.record("info", format, args...)
+}
+
+func (l *recordingLogger) Warningf(format string, args ...interface{}) {
+	l.record("warning", format, args...)
+}
+
+func (l *recordingLogger) Errorf(format string, args ...interface{}) {
+	l.record("error", format, args...)
+}
+
+func TestPoolLogsHealthChanges(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "control.sock")
+	pool, _ := NewPool([]Endpoint{{Name: "controller", Dialer: UnixDialer{Path: socket}}}, time.Second, time.Minute)
+	logger := &recordingLogger{}
+	pool.SetLogger(logger)
+	work := &recordingLogger{}
+	pool.SetWorkLogger(work)
+	ctx := context.Background()
+
+	// Repeated failures are logged once
+	pool.Check(ctx)
+	pool.Check(ctx)
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	startFakeControl(t, listener, "controller")
+	pool.Check(ctx)
+
+	if len(logger.messages) != 2 ||
+		!strings.HasPrefix(logger.messages[0], "warning: Receptor control service controller is unhealthy") ||
+		logger.messages[1] != "info: Receptor control service controller is healthy (node controller)" {
+		t.Errorf("Unexpected health log messages: %q", logger.messages)
+	}
+
+	client, _ := pool.Client(ctx)
+	unitID, err := client.SubmitWork(ctx, WorkRequest{WorkType: "echo", Payload: []byte("hi")})
+	if err != nil {
+		t.Fatalf("SubmitWork returned error: %v", err)
+	}
+	if len(work.messages) != 1 || !strings.Contains(work.messages[0], unitID) {
+		t.Errorf("Expected the submission to be logged, got %q", work.messages)
+	}
+}
@@ -0,0 +1,97 @@
+package receptor
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// DefaultTokenExpiration is how long work-signing tokens stay valid when
+// no expiration is configured
+const DefaultTokenExpiration = 5 * time.Minute
+
+// WorkSigner creates the work-signing tokens that nodes whose work-command
+// sets verifysignature check before running work: RS256 JWTs whose
+// audience is the target node, as created by a node's work-signing
+// privatekey and tokenexpiration settings
+type WorkSigner struct {
+	key        *rsa.PrivateKey
+	expiration time.Duration
+}
+
+// NewWorkSigner loads a PEM-encoded RSA private key, in PKCS #1 or PKCS #8
+// form, from keyFile. Tokens expire after expiration, or
+// DefaultTokenExpiration if it is not positive.
+func NewWorkSigner(keyFile string, expiration time.Duration) (*WorkSigner, error) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading work-signing key: %w", err)
+	}
+	key, err := parseRSAPrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing work-signing key %s: %w", keyFile, err)
+	}
+	if expiration <= 0 {
+		expiration = DefaultTokenExpiration
+	}
+	return &WorkSigner{key: key, expiration: expiration}, nil
+}
+
+// parseRSAPrivateKey decodes the first PEM block of data as an RSA key
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.New("not a PKCS #1 or PKCS #8 private key")
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("expected an RSA key, got %T", parsed)
+	}
+	return key, nil
+}
+
+// signingClaims are the JWT claims of a work-signing token
+type signingClaims struct {
+	Audience  []string `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+}
+
+// Sign returns a token authorizing work on nodeID
+func (s *WorkSigner) Sign(nodeID string) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(signingClaims{
+		Audience:  []string{nodeID},
+		ExpiresAt: time.Now().Add(s.expiration).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	encoding := base64.RawURLEncoding
+	signed := encoding.EncodeToString(header) + "." + encoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("signing work for %s: %w", nodeID, err)
+	}
+	return signed + "." + encoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,111 @@
+package receptor
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeKey writes a throwaway private key as PEM and returns its path
+func writeKey(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "work-signing.key")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestWorkSignerSign(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8, _ := x509.MarshalPKCS8PrivateKey(key)
+	keyFiles := map[string]string{
+		"pkcs1": writeKey(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key)),
+		"pkcs8": writeKey(t, "PRIVATE KEY", pkcs8),
+	}
+
+	for name, keyFile := range keyFiles {
+		t.Run(name, func(t *testing.T) {
+			signer, err := NewWorkSigner(keyFile, time.Hour)
+			if err != nil {
+				t.Fatalf("NewWorkSigner returned error: %v", err)
+			}
+			token, err := signer.Sign("worker-01")
+			if err != nil {
+				t.Fatalf("Sign returned error: %v", err)
+			}
+
+			parts := strings.Split(token, ".")
+			if len(parts) != 3 {
+				t.Fatalf("Expected a three-part JWT, got %q", token)
+			}
+			header, _ := base64.RawURLEncoding.DecodeString(parts[0])
+			if string(header) != `{"alg":"RS256","typ":"JWT"}` {
+				t.Errorf("Unexpected header %s", header)
+			}
+
+			payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+			var claims signingClaims
+			if err := json.Unmarshal(payload, &claims); err != nil {
+				t.Fatalf("Invalid claims %s: %v", payload, err)
+			}
+			if len(claims.Audience) != 1 || claims.Audience[0] != "worker-01" {
+				t.Errorf("Expected audience worker-01, got %v", claims.Audience)
+			}
+			if expires := time.Until(time.Unix(claims.ExpiresAt, 0)); expires < 59*time.Minute || expires > time.Hour {
+				t.Errorf("Expected the token to expire in an hour, got %s", expires)
+			}
+
+			signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+			digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+			if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+				t.Errorf("Signature does not verify: %v", err)
+			}
+		})
+	}
+}
+
+func TestWorkSignerDefaultExpiration(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	signer, err := NewWorkSigner(writeKey(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key)), 0)
+	if err != nil {
+		t.Fatalf("NewWorkSigner returned error: %v", err)
+	}
+	if signer.expiration != DefaultTokenExpiration {
+		t.Errorf("Expected the default expiration, got %s", signer.expiration)
+	}
+}
+
+func TestNewWorkSignerErrors(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecDER, _ := x509.MarshalPKCS8PrivateKey(ecKey)
+	notPEM := filepath.Join(t.TempDir(), "key.txt")
+	os.WriteFile(notPEM, []byte("not a key"), 0o600)
+
+	tests := map[string]string{
+		"missing": filepath.Join(t.TempDir(), "missing.key"),
+		"not PEM": notPEM,
+		"not RSA": writeKey(t, "PRIVATE KEY", ecDER),
+		"garbage": writeKey(t, "RSA PRIVATE KEY", []byte("garbage")),
+	}
+	for name, keyFile := range tests {
+		if _, err := NewWorkSigner(keyFile, time.Minute); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package receptor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+)
+
+// TLSOptions configures a TLS connection to a control service TCP listener,
+// such as one exposed with "tcplisten" and "tcptls" in control-service
+type TLSOptions struct {
+	// CertFile and KeyFile hold the client certificate presented to
+	// listeners that set requireclientcert
+	CertFile string
+	KeyFile  string
+	// CAFile holds the CA used to verify the control service certificate;
+	// empty means the system roots
+	CAFile string
+	// ServerName overrides the name checked against the server certificate
+	ServerName string
+	// InsecureSkipVerify disables server certificate verification
+	InsecureSkipVerify bool
+}
+
+// Config builds a tls.Config from the options
+func (o TLSOptions) Config() (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         o.ServerName,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+
+	if o.CertFile != "" || o.KeyFile != "" {
+		if o.CertFile == "" || o.KeyFile == "" {
+			return nil, errors.New("TLS client certificate and key must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading TLS client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading TLS CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", o.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	return config, nil
+}
+
+// TLSDialer connects to a control service TCP listener over TLS
+type TLSDialer struct {
+	Address string
+	Config  *tls.Config
+}
+
+// NewTLSDialer creates a dialer for address using opts. When opts does not
+// set a server name, the host part of address is used.
+func NewTLSDialer(address string, opts TLSOptions) (*TLSDialer, error) {
+	config, err := opts.Config()
+	if err != nil {
+		return nil, err
+	}
+	if config.ServerName == "" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid control service address %q: %w", address, err)
+		}
+		config.ServerName = host
+	}
+	return &TLSDialer{Address: address, Config: config}, nil
+}
+
+// DialContext implements Dialer
+func (d *TLSDialer) DialContext(ctx context.Context) (net.Conn, error) {
+	dialer := &tls.Dialer{Config: d.Config}
+	return dialer.DialContext(ctx, "tcp", d.Address)
+}
@@ -0,0 +1,195 @@
+package receptor
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testPKI is a throwaway CA with a server and a client certificate
+type testPKI struct {
+	caFile     string
+	serverCert tls.Certificate
+	clientCert string
+	clientKey  string
+	caPool     *x509.CertPool
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	dir := t.TempDir()
+
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	caCert, _ := x509.ParseCertificate(caDER)
+
+	issue := func(serial int64, name string, usage x509.ExtKeyUsage) ([]byte, *ecdsa.PrivateKey) {
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: name},
+			DNSNames:     []string{name},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatalf("Failed to issue %s certificate: %v", name, err)
+		}
+		return der, key
+	}
+
+	writePEM := func(name, blockType string, der []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		return path
+	}
+
+	serverDER, serverKey := issue(2, "controller", x509.ExtKeyUsageServerAuth)
+	clientDER, clientKey := issue(3, "mcp-server", x509.ExtKeyUsageClientAuth)
+	clientKeyDER, _ := x509.MarshalECPrivateKey(clientKey)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+
+	return &testPKI{
+		caFile: writePEM("ca.crt", "CERTIFICATE", caDER),
+		serverCert: tls.Certificate{
+			Certificate: [][]byte{serverDER},
+			PrivateKey:  serverKey,
+		},
+		clientCert: writePEM("client.crt", "CERTIFICATE", clientDER),
+		clientKey:  writePEM("client.key", "EC PRIVATE KEY", clientKeyDER),
+		caPool:     pool,
+	}
+}
+
+// listenTLS starts a TLS listener that requires client certificates, like
+// the mcp-server-tls listener in prod-controller.yaml
+func (p *testPKI) listenTLS(t *testing.T) net.Listener {
+	t.Helper()
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{p.serverCert},
+		ClientCAs:    p.caPool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	return listener
+}
+
+func TestTLSDialerMutualTLS(t *testing.T) {
+	pki := newTestPKI(t)
+	fake := startFakeControl(t, pki.listenTLS(t), "prod-controller")
+
+	dialer, err := NewTLSDialer(fake.listener.Addr().String(), TLSOptions{
+		CertFile: pki.clientCert,
+		KeyFile:  pki.clientKey,
+		CAFile:   pki.caFile,
+	})
+	if err != nil {
+		t.Fatalf("NewTLSDialer returned error: %v", err)
+	}
+
+	client := NewClient(dialer, 5*time.Second)
+	status, err := client.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status over TLS returned error: %v", err)
+	}
+
+	if status.NodeID != "prod-controller" {
+		t.Errorf("Expected node ID 'prod-controller', got '%s'", status.NodeID)
+	}
+
+	// Submitting work needs a TLS half-close to signal end of stdin
+	unitID, err := client.SubmitWork(context.Background(), WorkRequest{
+		WorkType: "echo",
+		Payload:  []byte("over tls"),
+	})
+	if err != nil {
+		t.Fatalf("SubmitWork over TLS returned error: %v", err)
+	}
+
+	if fake.stdin[unitID] != "over tls" {
+		t.Errorf("Expected payload 'over tls', got '%s'", fake.stdin[unitID])
+	}
+}
+
+func TestTLSDialerWithoutClientCert(t *testing.T) {
+	pki := newTestPKI(t)
+	fake := startFakeControl(t, pki.listenTLS(t), "prod-controller")
+
+	dialer, err := NewTLSDialer(fake.listener.Addr().String(), TLSOptions{CAFile: pki.caFile})
+	if err != nil {
+		t.Fatalf("NewTLSDialer returned error: %v", err)
+	}
+
+	if _, err := NewClient(dialer, 5*time.Second).Status(context.Background()); err == nil {
+		t.Fatal("Expected error when the listener requires a client certificate")
+	}
+}
+
+func TestTLSDialerUntrustedServer(t *testing.T) {
+	pki := newTestPKI(t)
+	fake := startFakeControl(t, pki.listenTLS(t), "prod-controller")
+
+	// Without the CA the server certificate cannot be verified
+	dialer, err := NewTLSDialer(fake.listener.Addr().String(), TLSOptions{
+		CertFile: pki.clientCert,
+		KeyFile:  pki.clientKey,
+	})
+	if err != nil {
+		t.Fatalf("NewTLSDialer returned error: %v", err)
+	}
+
+	if _, err := NewClient(dialer, 5*time.Second).Status(context.Background()); err == nil {
+		t.Fatal("Expected certificate verification error")
+	}
+}
+
+func TestTLSOptionsConfig(t *testing.T) {
+	if _, err := (TLSOptions{CertFile: "client.crt"}).Config(); err == nil {
+		t.Error("Expected error when key is missing")
+	}
+
+	if _, err := (TLSOptions{CAFile: filepath.Join(t.TempDir(), "missing.crt")}).Config(); err == nil {
+		t.Error("Expected error for missing CA file")
+	}
+
+	dialer, err := NewTLSDialer("controller.example.com:8888", TLSOptions{})
+	if err != nil {
+		t.Fatalf("NewTLSDialer returned error: %v", err)
+	}
+	if dialer.Config.ServerName != "controller.example.com" {
+		t.Errorf("Expected server name from address, got '%s'", dialer.Config.ServerName)
+	}
+}
@@ -0,0 +1,150 @@
+package receptor
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Status is the response to the control service "status" command
+type Status struct {
+	NodeID               string                        `json:"NodeID"`
+	SystemCPUCount       int                           `json:"SystemCPUCount"`
+	SystemMemoryMiB      int                           `json:"SystemMemoryMiB"`
+	Version              string                        `json:"Version"`
+	Connections          []Connection                  `json:"Connections"`
+	RoutingTable         map[string]string             `json:"RoutingTable"`
+	Advertisements       []Advertisement               `json:"Advertisements"`
+	KnownConnectionCosts map[string]map[string]float64 `json:"KnownConnectionCosts"`
+}
+
+// Connection describes a direct connection from the local node to a peer
+type Connection struct {
+	NodeID string  `json:"NodeID"`
+	Cost   float64 `json:"Cost"`
+}
+
+// Advertisement is a service advertisement seen by the local node
+type Advertisement struct {
+	NodeID       string            `json:"NodeID"`
+	Service      string            `json:"Service"`
+	Time         time.Time         `json:"Time"`
+	ConnType     int               `json:"ConnType"`
+	Tags         map[string]string `json:"Tags,omitempty"`
+	WorkCommands []WorkCommand     `json:"WorkCommands,omitempty"`
+}
+
+// WorkCommand is a work type advertised by a node
+type WorkCommand struct {
+	WorkType string `json:"WorkType"`
+	Secure   bool   `json:"Secure"`
+}
+
+// Nodes returns the IDs of every node known to the local node, including
+// itself, in no particular order
+func (s *Status) Nodes() []string {
+	seen := map[string]bool{}
+	var nodes []string
+	add := func(id string) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			nodes = append(nodes, id)
+		}
+	}
+
+	add(s.NodeID)
+	for node := range s.KnownConnectionCosts {
+		add(node)
+	}
+	for node := range s.RoutingTable {
+		add(node)
+	}
+	for _, ad := range s.Advertisements {
+		add(ad.NodeID)
+	}
+	return nodes
+}
+
+// WorkState is the state of a Receptor work unit
+type WorkState int
+
+// Work unit states, matching Receptor's workceptor package
+const (
+	WorkStatePending WorkState = iota
+	WorkStateRunning
+	WorkStateSucceeded
+	WorkStateFailed
+	WorkStateCanceled
+)
+
+// String returns the state name as reported by Receptor
+func (s WorkState) String() string {
+	switch s {
+	case WorkStatePending:
+		return "Pending"
+	case WorkStateRunning:
+		return "Running"
+	case WorkStateSucceeded:
+		return "Succeeded"
+	case WorkStateFailed:
+		return "Failed"
+	case WorkStateCanceled:
+		return "Canceled"
+	default:
+		return "Unknown"
+	}
+}
+
+// Final reports whether a unit in this state will no longer change state
+func (s WorkState) Final() bool {
+	return s == WorkStateSucceeded || s == WorkStateFailed || s == WorkStateCanceled
+}
+
+// WorkStatus is the status of a single work unit
+type WorkStatus struct {
+	State      WorkState       `json:"State"`
+	StateName  string          `json:"StateName,omitempty"`
+	Detail     string          `json:"Detail"`
+	StdoutSize int64           `json:"StdoutSize"`
+	WorkType   string          `json:"WorkType"`
+	ExtraData  json.RawMessage `json:"ExtraData,omitempty"`
+}
+
+// RemoteExtraData is the ExtraData of a unit submitted to another node,
+// whose WorkType is "remote"
+type RemoteExtraData struct {
+	RemoteNode     string `json:"RemoteNode"`
+	RemoteWorkType string `json:"RemoteWorkType"`
+	RemoteUnitID   string `json:"RemoteUnitID"`
+}
+
+// Remote returns where a unit submitted to another node runs, or nil for a
+// unit that runs on the node of the control service
+func (s *WorkStatus) Remote() *RemoteExtraData {
+	if len(s.ExtraData) == 0 {
+		return nil
+	}
+	var remote RemoteExtraData
+	if err := json.Unmarshal(s.ExtraData, &remote); err != nil || remote.RemoteNode == "" {
+		return nil
+	}
+	return &remote
+}
+
+// WorkRequest describes a unit of work to submit
+type WorkRequest struct {
+	// Node is the node that should execute the work; empty means the
+	// node the control service runs on
+	Node string
+	// WorkType is the worktype name configured on the target node
+	WorkType string
+	// Payload is sent to the work unit's stdin
+	Payload []byte
+	// Params are merged into the submit command, e.g. "params" for
+	// work-command runtime parameters
+	Params map[string]string
+	// SignWork asks the control service to sign work it forwards to
+	// another node with its own work-signing key
+	SignWork bool
+	// Signature is a work-signing token for the target node, see WorkSigner
+	Signature string
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package workstore
+
+import "os"
+
+// lockFile is a no-op where flock is unavailable; only one server should
+// use a store at a time
+func lockFile(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,24 @@
+//go:build unix
+
+package workstore
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive lock on file, failing with ErrLocked rather
+// than waiting when another process holds it. The lock is released when
+// the file is closed.
+func lockFile(file *os.File) error {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return ErrLocked
+	}
+	if err != nil {
+		return fmt.Errorf("locking work store: %w", err)
+	}
+	return nil
+}
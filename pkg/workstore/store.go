@@ -0,0 +1,333 @@
+// Package workstore records the work units submitted through the MCP server
+// in a JSON-lines file, so their queue and history survive restarts.
+package workstore
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// compactSlack is how many superseded lines the file may hold beyond its
+// live records before it is rewritten
+const compactSlack = 256
+
+// ErrLocked is returned by Open when another process has the store open
+var ErrLocked = errors.New("work store is in use by another process")
+
+// Record is a work unit submitted through the MCP server
+type Record struct {
+	UnitID string `json:"unit_id"`
+	// Client is the name and version of the MCP client that submitted
+	// the unit, if known
+	Client     string `json:"client,omitempty"`
+	Node       string `json:"node"`
+	WorkType   string `json:"work_type"`
+	ParamsHash string `json:"params_hash,omitempty"`
+
+	SubmittedAt time.Time `json:"submitted_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// FinishedAt is set once the unit reaches a final state
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	// FetchedAt is when the output of the finished unit was last read
+	FetchedAt *time.Time `json:"fetched_at,omitempty"`
+	// ReleasedAt is when the unit was released from the mesh, deleting
+	// its files
+	ReleasedAt *time.Time `json:"released_at,omitempty"`
+
+	State      string `json:"state"`
+	Detail     string `json:"detail,omitempty"`
+	StdoutSize int64  `json:"stdout_size"`
+}
+
+// Finished reports whether the unit has reached a final state
+func (r Record) Finished() bool {
+	return r.FinishedAt != nil
+}
+
+// Released reports whether the unit no longer exists on the mesh
+func (r Record) Released() bool {
+	return r.ReleasedAt != nil
+}
+
+// Store holds the records of submitted work units. Every change is
+// appended to the file as a full record; the last line for a unit wins.
+// The file is rewritten without superseded lines once they accumulate.
+// A lock file next to it keeps other processes from opening the store
+// while it is in use.
+type Store struct {
+	path       string
+	maxHistory int
+	lock       *os.File
+
+	mu      sync.Mutex
+	file    *os.File
+	records map[string]Record
+	lines   int
+}
+
+// Open loads the store at path, creating it if needed. At most maxHistory
+// finished units are kept, the oldest being dropped first; 0 keeps all.
+// Unfinished units are always kept. Open fails with ErrLocked while
+// another process has the store open.
+func Open(path string, maxHistory int) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating work store directory: %w", err)
+	}
+
+	// Lock a separate file, since compaction replaces the store's file
+	lock, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening work store lock: %w", err)
+	}
+	if err := lockFile(lock); err != nil {
+		lock.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	s := &Store{path: path, maxHistory: maxHistory, lock: lock, records: map[string]Record{}}
+	if err := s.load(); err != nil {
+		lock.Close()
+		return nil, err
+	}
+	s.prune()
+	if err := s.compact(); err != nil {
+		lock.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// load replays the file into memory. Lines that do not parse, such as a
+// final line cut short by a crash, are skipped.
+func (s *Store) load() error {
+	file, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("opening work store: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil || record.UnitID == "" {
+			continue
+		}
+		s.records[record.UnitID] = record
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading work store: %w", err)
+	}
+	return nil
+}
+
+// Path returns the file the store is kept in
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Close closes the store's file and releases its lock
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.file.Close()
+	s.lock.Close()
+	return err
+}
+
+// Put records a new unit or the latest state of a known one
+func (s *Store) Put(record Record) error {
+	if record.UnitID == "" {
+		return errors.New("work store record has no unit ID")
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.write(record, data)
+}
+
+// Update applies fn to the record of a known unit and stores the result,
+// holding the store's lock throughout so concurrent updates of the same
+// unit are not lost. Unknown units, and records fn leaves unchanged, are
+// not written.
+func (s *Store) Update(unitID string, fn func(*Record)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[unitID]
+	if !ok {
+		return nil
+	}
+	before, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	fn(&record)
+	record.UnitID = unitID
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(before, data) {
+		return nil
+	}
+	return s.write(record, data)
+}
+
+// write appends the encoded record to the file and applies it; s.mu must
+// be held
+func (s *Store) write(record Record, data []byte) error {
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("writing work store: %w", err)
+	}
+	s.lines++
+	s.records[record.UnitID] = record
+
+	if record.Finished() {
+		s.prune()
+	}
+	if s.lines > len(s.records)+compactSlack {
+		return s.compact()
+	}
+	return nil
+}
+
+// Get returns the record of a unit
+func (s *Store) Get(unitID string) (Record, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[unitID]
+	return record, ok
+}
+
+// Active returns the unfinished units, oldest submission first
+func (s *Store) Active() []Record {
+	active := s.filter(func(r Record) bool { return !r.Finished() })
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].SubmittedAt.Before(active[j].SubmittedAt)
+	})
+	return active
+}
+
+// History returns the finished units, most recently finished first
+func (s *Store) History() []Record {
+	history := s.filter(Record.Finished)
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].FinishedAt.After(*history[j].FinishedAt)
+	})
+	return history
+}
+
+// filter returns the records matching keep, in no particular order
+func (s *Store) filter(keep func(Record) bool) []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records := []Record{}
+	for _, record := range s.records {
+		if keep(record) {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// prune drops the oldest finished units beyond maxHistory. Dropped units
+// leave the file at the next compaction.
+func (s *Store) prune() {
+	if s.maxHistory <= 0 {
+		return
+	}
+	var finished []Record
+	for _, record := range s.records {
+		if record.Finished() {
+			finished = append(finished, record)
+		}
+	}
+	if len(finished) <= s.maxHistory {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].FinishedAt.Before(*finished[j].FinishedAt)
+	})
+	for _, record := range finished[:len(finished)-s.maxHistory] {
+		delete(s.records, record.UnitID)
+	}
+}
+
+// compact rewrites the file with one line per live record, replacing it
+// atomically, and reopens it for appending
+func (s *Store) compact() error {
+	records := make([]Record, 0, len(s.records))
+	for _, record := range s.records {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].UpdatedAt.Before(records[j].UpdatedAt)
+	})
+
+	tmp := s.path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("compacting work store: %w", err)
+	}
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			file.Close()
+			return fmt.Errorf("compacting work store: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("compacting work store: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("compacting work store: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("compacting work store: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("compacting work store: %w", err)
+	}
+
+	if s.file != nil {
+		s.file.Close()
+	}
+	s.file, err = os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening work store: %w", err)
+	}
+	s.lines = len(records)
+	return nil
+}
+
+// HashParams returns a stable digest of submit parameters, so units
+// submitted with the same parameters can be matched without storing them
+func HashParams(params map[string]string) string {
+	if len(params) == 0 {
+		return ""
+	}
+	// Maps are encoded with sorted keys
+	data, _ := json.Marshal(params)
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,200 @@
+package workstore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func submitted(id string, minute int) Record {
+	at := epoch.Add(time.Duration(minute) * time.Minute)
+	return Record{UnitID: id, Node: "worker-01", WorkType: "echo", SubmittedAt: at, UpdatedAt: at, State: "Pending"}
+}
+
+func finished(record Record, minute int, state string) Record {
+	at := epoch.Add(time.Duration(minute) * time.Minute)
+	record.UpdatedAt = at
+	record.FinishedAt = &at
+	record.State = state
+	return record
+}
+
+func openStore(t *testing.T, path string, maxHistory int) *Store {
+	t.Helper()
+	store, err := Open(path, maxHistory)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func unitIDs(records []Record) string {
+	var ids []string
+	for _, record := range records {
+		ids = append(ids, record.UnitID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestStoreSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history", "work.jsonl")
+	store := openStore(t, path, 0)
+
+	a, b := submitted("unitA", 0), submitted("unitB", 1)
+	a.Client = "claude-ai/0.1.0"
+	released := finished(a, 2, "Succeeded")
+	released.ReleasedAt = released.FinishedAt
+	for _, record := range []Record{a, b, finished(a, 2, "Succeeded"), released} {
+		if err := store.Put(record); err != nil {
+			t.Fatalf("Put returned error: %v", err)
+		}
+	}
+	store.Close()
+
+	reopened := openStore(t, path, 0)
+	if got := unitIDs(reopened.Active()); got != "unitB" {
+		t.Errorf("Expected unitB to be active, got %s", got)
+	}
+	history := reopened.History()
+	if unitIDs(history) != "unitA" || history[0].State != "Succeeded" || history[0].Client != "claude-ai/0.1.0" || !history[0].Released() {
+		t.Errorf("Expected unitA to have succeeded and been released, got %+v", history)
+	}
+}
+
+func TestStoreRetention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "work.jsonl")
+	store := openStore(t, path, 2)
+
+	active := submitted("unitZ", 0)
+	store.Put(active)
+	for i, id := range []string{"unitA", "unitB", "unitC"} {
+		record := submitted(id, i)
+		store.Put(record)
+		store.Put(finished(record, 10+i, "Failed"))
+	}
+
+	if got := unitIDs(store.History()); got != "unitC,unitB" {
+		t.Errorf("Expected the two most recent finished units, got %s", got)
+	}
+	if got := unitIDs(store.Active()); got != "unitZ" {
+		t.Errorf("Expected unfinished units to be kept, got %s", got)
+	}
+	if _, ok := store.Get("unitA"); ok {
+		t.Error("Expected unitA to be dropped")
+	}
+
+	// A lower limit applies on the next open
+	store.Close()
+	reopened := openStore(t, path, 1)
+	if got := unitIDs(reopened.History()); got != "unitC" {
+		t.Errorf("Expected one finished unit after reopening, got %s", got)
+	}
+	data, _ := os.ReadFile(path)
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("Expected the file to be compacted to 2 lines, got %d", lines)
+	}
+}
+
+func TestStoreCompactsSupersededLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "work.jsonl")
+	store := openStore(t, path, 0)
+
+	record := submitted("unitA", 0)
+	for i := 0; i < compactSlack+10; i++ {
+		record.StdoutSize = int64(i)
+		if err := store.Put(record); err != nil {
+			t.Fatalf("Put returned error: %v", err)
+		}
+	}
+
+	data, _ := os.ReadFile(path)
+	if lines := strings.Count(string(data), "\n"); lines > compactSlack {
+		t.Errorf("Expected the file to be compacted, got %d lines", lines)
+	}
+	if got, _ := store.Get("unitA"); got.StdoutSize != compactSlack+9 {
+		t.Errorf("Expected the latest record, got %+v", got)
+	}
+}
+
+func TestStoreSkipsTruncatedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "work.jsonl")
+	content := `{"unit_id":"unitA","node":"worker-01","work_type":"echo","state":"Running"}` + "\n" + `{"unit_id":"unitB","no`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	store := openStore(t, path, 0)
+	if got := unitIDs(store.Active()); got != "unitA" {
+		t.Errorf("Expected only the complete record, got %s", got)
+	}
+}
+
+func TestStoreUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "work.jsonl")
+	store := openStore(t, path, 0)
+	store.Put(submitted("unitA", 0))
+
+	// Concurrent updates of different fields are all kept
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			store.Update("unitA", func(record *Record) {
+				if i%2 == 0 {
+					record.StdoutSize++
+				} else {
+					record.Detail += "x"
+				}
+			})
+		}(i)
+	}
+	wg.Wait()
+	if got, _ := store.Get("unitA"); got.StdoutSize != 10 || len(got.Detail) != 10 {
+		t.Errorf("Expected every update to be applied, got %+v", got)
+	}
+
+	// Unchanged records and unknown units are not written
+	before, _ := os.ReadFile(path)
+	if err := store.Update("unitA", func(record *Record) {}); err != nil {
+		t.Errorf("Update returned error: %v", err)
+	}
+	called := false
+	if err := store.Update("unitB", func(record *Record) { called = true }); err != nil || called {
+		t.Errorf("Expected an unknown unit to be skipped, got called=%v err=%v", called, err)
+	}
+	after, _ := os.ReadFile(path)
+	if len(after) != len(before) {
+		t.Errorf("Expected no lines to be written, file grew from %d to %d bytes", len(before), len(after))
+	}
+}
+
+func TestStoreLocked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "work.jsonl")
+	store := openStore(t, path, 0)
+
+	if _, err := Open(path, 0); !errors.Is(err, ErrLocked) {
+		t.Fatalf("Expected ErrLocked while the store is open, got %v", err)
+	}
+
+	store.Close()
+	openStore(t, path, 0)
+}
+
+func TestHashParams(t *testing.T) {
+	a := HashParams(map[string]string{"params": "-v", "node": "x"})
+	b := HashParams(map[string]string{"node": "x", "params": "-v"})
+	if a != b || !strings.HasPrefix(a, "sha256:") {
+		t.Errorf("Expected equal sha256 digests, got %s and %s", a, b)
+	}
+	if HashParams(nil) != "" {
+		t.Error("Expected no digest without params")
+	}
+}